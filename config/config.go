@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 )
 
@@ -15,6 +16,70 @@ type Config struct {
 	Graph        GraphConfig        `json:"graph"`
 	Path         PathConfig         `json:"path"`
 	TripDistance TripDistanceConfig `json:"tripDistance"`
+	Incidents    []IncidentConfig   `json:"incidents"`
+	SpeedLimits  SpeedLimitConfig   `json:"speedLimits"`
+}
+
+// SpeedLimitConfig 保存可变限速控制相关的配置项
+// 单元格的限速取路网原限速、生效的时段限速和拥堵规则限速中的最小值
+type SpeedLimitConfig struct {
+	// 按时段生效的限速，每天重复执行
+	Schedules []SpeedLimitSchedule `json:"schedules"`
+
+	// 按拥堵规则降低限速
+	Rule SpeedLimitRule `json:"rule"`
+}
+
+// SpeedLimitSchedule 表示一天中指定时段内一组单元格的限速
+type SpeedLimitSchedule struct {
+	Name string `json:"name"`
+
+	// 受控的单元格ID和路段ID，路段包含的所有单元格均受控
+	Cells []int64 `json:"cells"`
+	Links []int64 `json:"links"`
+
+	// 生效时段(一天内的时间步)，左闭右开
+	StartStep int `json:"startStep"`
+	EndStep   int `json:"endStep"`
+
+	// 时段内的限速(单元格/时间步)
+	MaxSpeed int `json:"maxSpeed"`
+}
+
+// SpeedLimitRule 表示在拥堵上游降低限速的规则
+// 单元格拥堵程度(占用/容量)达到阈值时，其上游若干单元格的限速降低为ReducedSpeed
+type SpeedLimitRule struct {
+	// 是否启用拥堵规则
+	Enabled bool `json:"enabled"`
+
+	// 判断拥堵的占用/容量阈值
+	JamThreshold float64 `json:"jamThreshold"`
+
+	// 拥堵单元格上游降低限速的单元格层数，遇到红绿灯时停止
+	UpstreamCells int `json:"upstreamCells"`
+
+	// 降低后的限速(单元格/时间步)
+	ReducedSpeed int `json:"reducedSpeed"`
+
+	// 检测拥堵和更新限速的间隔(时间步)
+	Interval int `json:"interval"`
+}
+
+// IncidentConfig 表示一个在指定时间段内降低若干单元格容量的事故
+type IncidentConfig struct {
+	Name string `json:"name"`
+
+	// 受影响的单元格ID和路段ID，路段包含的所有单元格均受影响
+	Cells []int64 `json:"cells"`
+	Links []int64 `json:"links"`
+
+	// 事故开始的时间步(从仿真开始计)和持续时间步
+	StartStep int `json:"startStep"`
+	Duration  int `json:"duration"`
+
+	// 容量降低的比例，按单元格车道数换算为关闭的车道数(向上取整)，为1时单元格完全阻断
+	// 小于1的比例不能关闭单元格的全部车道，例如单车道单元格只能使用1
+	CapacityReduction float64 `json:"capacityReduction"`
 }
 
 // SimulationConfig 保存模拟相关的配置项
@@ -25,7 +90,10 @@ type SimulationConfig struct {
 
 // GraphConfig 保存路网相关的配置项
 type GraphConfig struct {
-	// 路网类型: "cycle" - 环形路网, "starRing" - 星形环形混合路网, "grid" - 网格状路网
+	// 合成路网(cycle, starRing, grid)中道路单元格的车道数
+	RoadLanes int `json:"roadLanes"`
+
+	// 路网类型: "cycle" - 环形路网, "starRing" - 星形环形混合路网, "grid" - 网格状路网, "tntp" - TNTP路网文件, "osm" - OSM XML文件, "file" - 已保存的路网JSON文件
 	GraphType string `json:"graphType"`
 
 	// 环形路网参数
@@ -46,12 +114,50 @@ type GraphConfig struct {
 		Cols         int `json:"cols"`
 		CellsPerEdge int `json:"cellsPerEdge"`
 	} `json:"gridGraph"`
+
+	// TNTP路网文件参数
+	// 编号小于<FIRST THRU NODE>的小区形心拆分为起点单元格(原编号)和终点单元格(最大节点ID+形心编号)，车辆不能穿行形心
+	TNTPGraph struct {
+		FilePath   string `json:"filePath"`
+		LengthUnit string `json:"lengthUnit"` // 路段长度单位: "ft", "mi", "km", "m"
+
+		// 每条车道的通行能力(veh/h)，用于由路段通行能力推算车道数，为0时所有路段均为单车道
+		LaneCapacity float64 `json:"laneCapacity"`
+	} `json:"tntpGraph"`
+
+	// OSM路网文件参数
+	OSMGraph struct {
+		FilePath string `json:"filePath"` // 本地OSM XML文件(*.osm)
+	} `json:"osmGraph"`
+
+	// 已保存路网文件参数
+	FileGraph struct {
+		FilePath string `json:"filePath"` // SaveGraphToJSON保存的*_Graph.json文件
+	} `json:"fileGraph"`
+
+	// 合流和分流单元格的通行规则
+	Junction JunctionConfig `json:"junction"`
+}
+
+// JunctionConfig 保存合流(入度>1)和分流(出度>1)单元格通行规则的配置项
+type JunctionConfig struct {
+	// 合流规则: "none" - 按协程顺序竞争, "priority" - 主路优先, "zipper" - 拉链式交替通行
+	MergeRule string `json:"mergeRule"`
+
+	// 分流规则: "legacy" - 以固定概率0.8通过, "speed" - 以不超过分流速度的速度通过
+	DivergeRule string `json:"divergeRule"`
+
+	// 通过分流单元格的最高速度(单元格/时间步)，仅在speed规则下有效
+	DivergeSpeed int `json:"divergeSpeed"`
 }
 
 // LoggingConfig 保存日志记录相关的配置项
 type LoggingConfig struct {
 	IntervalWriteToLog     int `json:"intervalWriteToLog"`
 	IntervalWriteOtherData int `json:"intervalWriteOtherData"`
+
+	// 路段数据的采样间隔，采样结果在每次写入日志时按路段平均后写入
+	IntervalRecordLinkData int `json:"intervalRecordLinkData"`
 }
 
 // DemandConfig 保存需求生成相关的配置项
@@ -60,12 +166,170 @@ type DemandConfig struct {
 	FixedNum          float64 `json:"fixedNum"`
 	DayRandomDisRange float64 `json:"dayRandomDisRange"`
 	RandomDisRange    float64 `json:"randomDisRange"`
+
+	// 一天的需求曲线，各值为对应时段占一天需求的份额
+	Profile struct {
+		// 需求曲线文件(T,Pro)，可以是任意时间分辨率，读取后重采样到oneDayTimeSteps
+		FilePath string `json:"filePath"`
+
+		// 重采样的插值方法: "linear" - 线性插值, "step" - 取所在时段的值
+		Interpolation string `json:"interpolation"`
+	} `json:"profile"`
+
+	// 行程清单，可以将生成的行程写入文件，或回放文件中的行程代替随机生成，使不同方案使用完全相同的需求
+	// 封闭车辆的每次行程都按车辆ID和行程序号记录，回放时车辆完成一次行程后按序号使用下一次行程
+	TripList struct {
+		// 模式: "none" - 不使用, "write" - 写入生成的行程, "replay" - 回放文件中的行程
+		Mode string `json:"mode"`
+
+		// 行程清单文件，写入模式下为空时写入data目录
+		FilePath string `json:"filePath"`
+
+		// 回放时是否使用文件中的路径，否则按当前的路径查找方法重新计算
+		UseRecordedPaths bool `json:"useRecordedPaths"`
+	} `json:"tripList"`
+
+	// 需求日历，为每个模拟日指定日类型，按日类型选择需求曲线、乘数和随机波动范围
+	Calendar DemandCalendarConfig `json:"calendar"`
+
+	// 交通小区，未指定文件时车辆的起终点从所有单元格中随机选择
+	Zones struct {
+		// 小区文件(Zone,Node,Type)，每行为一个小区ID、单元格ID和单元格类型(entry, exit, both)
+		FilePath string `json:"filePath"`
+	} `json:"zones"`
+
+	// 小区到小区的出行需求矩阵，未指定文件时在小区之间均匀选择起终点
+	ODMatrix struct {
+		// 出行需求矩阵文件(Origin,Destination,Trips)，出行量可以是出行次数或份额
+		FilePath string `json:"filePath"`
+
+		// 是否以矩阵中的出行总量作为一天的总需求，按需求曲线分配到各时间步，否则使用multiplier
+		UseTripTotal bool `json:"useTripTotal"`
+	} `json:"odMatrix"`
+}
+
+// DemandCalendarConfig 保存需求日历相关的配置项，未配置时每天都是"weekday"，使用默认需求曲线
+type DemandCalendarConfig struct {
+	// 按周期循环的日类型，第1个模拟日对应第一个元素，如5个"weekday"和2个"weekend"
+	Week []string `json:"week"`
+
+	// 指定模拟日的日类型，优先于week，如节假日
+	Days []CalendarDayConfig `json:"days"`
+
+	// 各日类型的需求配置，键为日类型，未配置的日类型使用默认需求曲线
+	Profiles map[string]DayProfileConfig `json:"profiles"`
+}
+
+// CalendarDayConfig 表示一个指定日类型的模拟日
+type CalendarDayConfig struct {
+	Day  int    `json:"day"` // 模拟日，从1开始
+	Type string `json:"type"`
+}
+
+// DayProfileConfig 保存一种日类型的需求配置
+type DayProfileConfig struct {
+	// 需求曲线文件，为空时使用demand.profile中的文件，按相同的插值方法重采样
+	FilePath string `json:"filePath"`
+
+	// 需求乘数，与demand.multiplier相乘，未设置时为1
+	Multiplier float64 `json:"multiplier"`
+
+	// 每天的随机波动范围(0-1)，为0时当天需求不随机波动，未设置时使用demand.dayRandomDisRange
+	Spread *float64 `json:"spread"`
 }
 
 // VehicleConfig 保存车辆相关的配置项
 type VehicleConfig struct {
-	NumClosedVehicle int `json:"numClosedVehicle"`
-	TraceInterval    int `json:"traceInterval"`
+	NumClosedVehicle int              `json:"numClosedVehicle"`
+	TraceInterval    int              `json:"traceInterval"`
+	LaneChange       LaneChangeConfig `json:"laneChange"`
+
+	// 按车辆类别配置的驾驶模型，键为车队组成中的类别名或"default", "closed", "scheduled"，
+	// 依次按车辆类别、封闭/计划车辆、"default"的顺序查找
+	DrivingModels map[string]DrivingModelConfig `json:"drivingModels"`
+
+	// 车队组成，车辆按份额随机选择类别，份额为0的类别不生成
+	Classes []VehicleClassConfig `json:"classes"`
+
+	// 封闭车辆的出行链，未配置活动链时封闭车辆到达终点后立即前往新的随机终点
+	Tours TourConfig `json:"tours"`
+}
+
+// TourConfig 保存封闭车辆出行链相关的配置项
+// 车辆按份额选择活动链，在每个活动地点停留一段时间后出发前往下一个活动，
+// 完成活动链后在以最后一个活动开始的活动链中重新选择
+type TourConfig struct {
+	Chains []TourChainConfig `json:"chains"`
+
+	// 各活动的停留时间分布，键为活动名称，未配置的活动不停留
+	Activities map[string]ActivityConfig `json:"activities"`
+}
+
+// TourChainConfig 保存一条活动链的配置项
+type TourChainConfig struct {
+	// 活动序列，如["home", "work", "shop", "home"]，第一个活动为车辆当前所在地点，
+	// 同一出行链中再次出现的活动返回之前的地点
+	Activities []string `json:"activities"`
+
+	// 在选择活动链时所占的份额，各活动链份额按总和归一化
+	Share float64 `json:"share"`
+}
+
+// ActivityConfig 保存一个活动的配置项
+type ActivityConfig struct {
+	// 停留时间分布: "fixed" - 固定为均值, "uniform" - 均值±半宽, "normal" - 正态分布, "exponential" - 指数分布
+	Distribution string `json:"distribution"`
+
+	// 停留时间的均值(时间步)，uniform时std为半宽，normal时为标准差
+	Mean float64 `json:"mean"`
+	Std  float64 `json:"std"`
+
+	// 最短停留时间(时间步)
+	Min int `json:"min"`
+
+	// 是否为固定地点(如家、工作地点)，固定地点在以后的出行链中保持不变，否则每条出行链重新选择
+	FixedLocation bool `json:"fixedLocation"`
+}
+
+// VehicleClassConfig 保存一个车辆类别的配置项
+type VehicleClassConfig struct {
+	Name string `json:"name"`
+
+	// 在生成车辆中所占的份额，各类别份额按总和归一化
+	Share float64 `json:"share"`
+
+	// 占用空间，不能超过单车道容量1.0：车辆只占据一个单元格的一条车道，
+	// 重型车辆(truck, bus)通过较低的最高速度、加速度和较高的随机减速概率区分
+	Occupy float64 `json:"occupy"`
+
+	// 车辆自身的最高速度(单元格/时间步)，为0时仅受单元格限速约束
+	MaxSpeed int `json:"maxSpeed"`
+
+	// 加速度和初始速度的取值范围（闭区间），随机减速概率的取值范围（左闭右开）
+	Acceleration    [2]int     `json:"acceleration"`
+	InitialVelocity [2]int     `json:"initialVelocity"`
+	SlowingProb     [2]float64 `json:"slowingProb"`
+}
+
+// DrivingModelConfig 保存驾驶模型相关的配置项
+type DrivingModelConfig struct {
+	// 驾驶模型: "nasch" - Nagel-Schreckenberg, "vdr" - 速度相关随机慢化, "tt" - Takayasu-Takayasu, "kkw" - Kerner-Klenov-Wolf
+	Model string `json:"model"`
+
+	// 静止车辆的慢启动概率（vdr, tt, kkw）
+	SlowToStartProb float64 `json:"slowToStartProb"`
+
+	// KKW模型的同步距离系数
+	SyncFactor float64 `json:"syncFactor"`
+}
+
+// LaneChangeConfig 保存多车道换道模型的配置项
+type LaneChangeConfig struct {
+	// 换道规则: "none" - 不换道, "symmetric" - 对称规则, "asymmetric" - 非对称规则（靠右行驶，向左超车）
+	Rule string `json:"rule"`
+
+	// 满足换道条件时实际换道的概率
+	Probability float64 `json:"probability"`
 }
 
 // TrafficLightChange 表示流量灯变化的配置
@@ -78,24 +342,149 @@ type TrafficLightChange struct {
 type TrafficLightConfig struct {
 	InitPhaseInterval int                  `json:"initPhaseInterval"`
 	Changes           []TrafficLightChange `json:"changes"`
+
+	// 交叉口每个相位绿灯后的黄灯时长和全红清空时长（时间步）
+	AmberTime  int `json:"amberTime"`
+	AllRedTime int `json:"allRedTime"`
+
+	// 信号控制方式
+	Control SignalControlConfig `json:"control"`
+
+	// 按红绿灯ID或交叉口ID指定的配时方案，未指定的使用路网生成器的默认配时
+	Timings []SignalTiming `json:"timings"`
+
+	// 按时段切换的配时方案，每天重复执行
+	Plans []SignalPlan `json:"plans"`
+}
+
+// SignalPlan 表示一个从一天中指定时间步开始生效的时段配时方案
+// 方案在下一个时段方案开始前一直有效，第一个方案开始前沿用前一天最后一个方案
+type SignalPlan struct {
+	Name string `json:"name"`
+
+	// 方案开始生效的时间步（一天内）
+	StartStep int `json:"startStep"`
+
+	// 对基础配时的周期倍数，为0时不缩放
+	Multiplier float64 `json:"multiplier"`
+
+	// 该时段覆盖基础配时的红绿灯和交叉口配时
+	Timings []SignalTiming `json:"timings"`
+}
+
+// SignalTiming 表示一组红绿灯或交叉口的配时方案，后面的方案覆盖前面的方案
+type SignalTiming struct {
+	// 方案适用的红绿灯ID或交叉口ID
+	IDs []int64 `json:"ids"`
+
+	// 周期长度，为0时保持原周期（交叉口指定PhaseGreens时由绿灯和清空时间决定）
+	Cycle int `json:"cycle"`
+
+	// 红绿灯的绿灯区间，为[0,0]时按周期比例缩放原绿灯区间
+	Green [2]int `json:"green"`
+
+	// 交叉口各相位的绿灯时长，为空时按周期比例分配原绿灯时长
+	PhaseGreens []int `json:"phaseGreens"`
+
+	// 相位差：仿真开始时周期内已经过的时间步；时段方案切换配时时，周期内的计数按(仿真时间步+相位差)对周期取余确定，
+	// 各信号始终对齐到同一时钟
+	Offset int `json:"offset"`
+}
+
+// SignalControlConfig 保存信号控制方式相关的配置项
+type SignalControlConfig struct {
+	// 控制方式: "fixed" - 固定配时, "actuated" - 感应控制, "maxPressure" - 最大压力控制
+	Mode string `json:"mode"`
+
+	// 按交叉口ID或独立红绿灯ID指定的控制方式，未指定的使用Mode
+	Overrides map[int64]string `json:"overrides"`
+
+	// 自适应控制参数（时间步）
+	MinGreen int `json:"minGreen"`
+	MaxGreen int `json:"maxGreen"`
+	GapTime  int `json:"gapTime"`
+
+	// 检测区长度：红绿灯上游和下游各检测的单元格层数
+	DetectorLength int `json:"detectorLength"`
 }
 
 // PathConfig 管理车辆路径选择相关的配置
 type PathConfig struct {
-	// 路径选择方法: "shortest" - 最短路径, "random" - 随机路径, "kShortest" - k条最短路径中选择
+	// 路径选择方法: "shortest" - 最短路径, "random" - 随机路径, "kShortest" - k条最短路径中选择, "travelTime" - 通行时间最短路径
 	PathMethod string `json:"pathMethod"`
 
+	// 按通行时间选择路径时单元格通行时间的更新参数
+	TravelTime struct {
+		// 更新间隔(时间步)
+		UpdateInterval int `json:"updateInterval"`
+
+		// 指数平滑中当前估计值的权重，为1时只使用当前估计值
+		Smoothing float64 `json:"smoothing"`
+	} `json:"travelTime"`
+
 	// k最短路径相关参数
 	KShortest struct {
 		// 计算的最短路径数量
 		K int `json:"k"`
 
-		// 路径选择策略: "random" - 随机选择, "weighted" - 加权选择
+		// 路径选择策略: "random" - 随机选择, "weighted" - 加权选择,
+		// "logit" - 多项Logit, "cLogit" - C-Logit, "pathSizeLogit" - 路径规模Logit
 		SelectionStrategy string `json:"selectionStrategy"`
 
 		// 路径长度权重因子，值越大对短路径的偏好越强（仅在weighted策略下有效）
 		LengthWeightFactor float64 `json:"lengthWeightFactor"`
+
+		// Logit模型的离散参数，值越大越倾向于选择广义费用最低的路径（仅在Logit类策略下有效，下同）
+		Dispersion float64 `json:"dispersion"`
+
+		// 广义费用中路径长度(单元格)和通行时间(时间步)的系数
+		LengthCoef float64 `json:"lengthCoef"`
+		TimeCoef   float64 `json:"timeCoef"`
+
+		// C-Logit共性因子的系数
+		CommonalityFactor float64 `json:"commonalityFactor"`
+
+		// 路径规模Logit中路径规模对数项的系数
+		PathSizeFactor float64 `json:"pathSizeFactor"`
 	} `json:"kShortest"`
+
+	// 行驶途中的动态重新规划路径
+	Reroute RerouteConfig `json:"reroute"`
+
+	// 跨天的路径学习
+	Learning RouteLearningConfig `json:"learning"`
+}
+
+// RouteLearningConfig 保存跨天路径学习相关的配置项
+// 驾驶员记住经历过的路径及其行程时间，每天结束时按指数平滑更新，次日在记忆中的路径和新候选路径之间按Logit模型选择
+type RouteLearningConfig struct {
+	// 记忆范围: "none" - 不学习, "od" - 同一起终点共享记忆, "driver" - 每个驾驶员各自记忆
+	Scope string `json:"scope"`
+
+	// 指数平滑中当天平均行程时间的权重
+	Smoothing float64 `json:"smoothing"`
+
+	// 按行程时间(时间步)选择路径的Logit离散参数
+	Dispersion float64 `json:"dispersion"`
+}
+
+// RerouteConfig 保存行驶途中动态重新规划路径相关的配置项
+// 途中重新规划总是选择按当前通行时间最快的路径(与pathMethod无关)
+type RerouteConfig struct {
+	// 可以在途中重新规划路径的车辆(使用导航的驾驶员)所占的份额，为0时不重新规划
+	Share float64 `json:"share"`
+
+	// 定期重新规划的间隔(时间步)，为0时不定期重新规划
+	Interval int `json:"interval"`
+
+	// 前方拥堵程度(占用/容量)达到阈值时重新规划，为0时不按拥堵重新规划
+	CongestionThreshold float64 `json:"congestionThreshold"`
+
+	// 判断前方拥堵程度时检查的单元格数量
+	Lookahead int `json:"lookahead"`
+
+	// 两次重新规划之间的最短间隔(时间步)
+	Cooldown int `json:"cooldown"`
 }
 
 // TripDistanceConfig 管理车辆出行距离相关的配置
@@ -117,6 +506,24 @@ type TripDistanceConfig struct {
 
 	// 最大距离倍数（相对于最大默认距离）
 	MaxDistMultiplier float64 `json:"maxDistMultiplier"`
+
+	// 经验出行距离分布，指定文件时按该分布抽样目标距离，并选择网络距离与之相近的终点，优先于上面的距离范围
+	Empirical struct {
+		// 分布文件(x,f(x))，x为出行距离
+		FilePath string `json:"filePath"`
+
+		// 文件中f(x)的类型: "cdf" - 累积分布(可以是百分比，按最大值归一化), "pdf" - 概率密度
+		Kind string `json:"kind"`
+
+		// 出行距离的单位: "mile", "km"
+		Unit string `json:"unit"`
+
+		// 终点网络距离与目标距离的相对误差上限
+		Tolerance float64 `json:"tolerance"`
+
+		// 找不到满足误差的终点时重新抽样目标距离的最大次数
+		MaxAttempts int `json:"maxAttempts"`
+	} `json:"empirical"`
 }
 
 var globalConfig *Config
@@ -165,11 +572,180 @@ func LoadConfig(filename string) error {
 		config.Graph.GridGraph.CellsPerEdge = 10 // 默认每条边上的元胞数
 	}
 
+	if config.Graph.RoadLanes <= 0 {
+		config.Graph.RoadLanes = 1 // 默认单车道
+	}
+
+	// 设置TNTP路网参数的默认值
+	if config.Graph.TNTPGraph.FilePath == "" {
+		config.Graph.TNTPGraph.FilePath = "./resources/Anaheim_net.tntp" // 默认使用Anaheim路网
+	}
+	if config.Graph.TNTPGraph.LengthUnit == "" {
+		config.Graph.TNTPGraph.LengthUnit = "ft" // Anaheim路网长度单位为英尺
+	}
+
+	// 设置合流和分流规则的默认值
+	if config.Graph.Junction.MergeRule == "" {
+		config.Graph.Junction.MergeRule = "priority" // 默认主路优先
+	}
+	switch config.Graph.Junction.MergeRule {
+	case "none", "priority", "zipper":
+	default:
+		return fmt.Errorf("graph.junction: 未知的合流规则: %s", config.Graph.Junction.MergeRule)
+	}
+	if config.Graph.Junction.DivergeRule == "" {
+		config.Graph.Junction.DivergeRule = "legacy" // 默认沿用固定通过概率
+	}
+	switch config.Graph.Junction.DivergeRule {
+	case "legacy", "speed":
+	default:
+		return fmt.Errorf("graph.junction: 未知的分流规则: %s", config.Graph.Junction.DivergeRule)
+	}
+	if config.Graph.Junction.DivergeSpeed <= 0 {
+		config.Graph.Junction.DivergeSpeed = 2 // 默认以2个单元格/时间步通过分流点
+	}
+
+	// 设置路段数据采样间隔的默认值
+	if config.Logging.IntervalRecordLinkData <= 0 {
+		config.Logging.IntervalRecordLinkData = 40 // 默认每40个时间步(1分钟)采样一次
+	}
+
+	// 设置交叉口清空时间的默认值
+	if config.TrafficLight.AmberTime <= 0 {
+		config.TrafficLight.AmberTime = 2 // 默认黄灯2个时间步(3秒)
+	}
+	if config.TrafficLight.AllRedTime <= 0 {
+		config.TrafficLight.AllRedTime = 1 // 默认全红1个时间步(1.5秒)
+	}
+
+	// 设置信号控制方式的默认值
+	if config.TrafficLight.Control.Mode == "" {
+		config.TrafficLight.Control.Mode = "fixed" // 默认固定配时
+	}
+	if config.TrafficLight.Control.MinGreen <= 0 {
+		config.TrafficLight.Control.MinGreen = 4 // 默认最小绿灯4个时间步(6秒)
+	}
+	if config.TrafficLight.Control.MaxGreen < config.TrafficLight.Control.MinGreen {
+		config.TrafficLight.Control.MaxGreen = max(30, config.TrafficLight.Control.MinGreen) // 默认最大绿灯30个时间步(45秒)
+	}
+	if config.TrafficLight.Control.GapTime <= 0 {
+		config.TrafficLight.Control.GapTime = 2 // 默认间隙2个时间步(3秒)
+	}
+	if config.TrafficLight.Control.DetectorLength <= 0 {
+		config.TrafficLight.Control.DetectorLength = 10 // 默认检测上下游10个单元格(75米)
+	}
+
+	// 检查配时方案
+	for i, timing := range config.TrafficLight.Timings {
+		if err := timing.validate(); err != nil {
+			return fmt.Errorf("trafficLight.timings[%d]: %v", i, err)
+		}
+	}
+	for i, plan := range config.TrafficLight.Plans {
+		if plan.StartStep < 0 || plan.Multiplier < 0 {
+			return fmt.Errorf("trafficLight.plans[%d]: 开始时间步和倍数不能为负", i)
+		}
+		for j, timing := range plan.Timings {
+			if err := timing.validate(); err != nil {
+				return fmt.Errorf("trafficLight.plans[%d].timings[%d]: %v", i, j, err)
+			}
+		}
+	}
+
+	// 设置需求曲线的默认值
+	if config.Demand.Profile.FilePath == "" {
+		config.Demand.Profile.FilePath = "./resources/DemandTimeDistribution_Smoothed.csv"
+	}
+	if config.Demand.Profile.Interpolation == "" {
+		config.Demand.Profile.Interpolation = "linear"
+	}
+	if config.Demand.Profile.Interpolation != "linear" && config.Demand.Profile.Interpolation != "step" {
+		return fmt.Errorf("demand.profile: 未知的插值方法: %s", config.Demand.Profile.Interpolation)
+	}
+
+	// 检查行程清单配置
+	if config.Demand.TripList.Mode == "" {
+		config.Demand.TripList.Mode = "none"
+	}
+	switch config.Demand.TripList.Mode {
+	case "none", "write":
+	case "replay":
+		if config.Demand.TripList.FilePath == "" {
+			return fmt.Errorf("demand.tripList: 回放模式需要指定行程清单文件")
+		}
+	default:
+		return fmt.Errorf("demand.tripList: 未知的模式: %s", config.Demand.TripList.Mode)
+	}
+
+	// 检查需求日历配置
+	for i, day := range config.Demand.Calendar.Days {
+		if day.Day < 1 || day.Type == "" {
+			return fmt.Errorf("demand.calendar.days[%d]: 模拟日必须为正，日类型不能为空", i)
+		}
+	}
+	for dayType, profile := range config.Demand.Calendar.Profiles {
+		if profile.Multiplier <= 0 {
+			profile.Multiplier = 1.0
+		}
+		if profile.Spread == nil {
+			spread := config.Demand.DayRandomDisRange
+			profile.Spread = &spread
+		}
+		if *profile.Spread < 0 || *profile.Spread > 1 {
+			return fmt.Errorf("demand.calendar.profiles.%s: 随机波动范围必须在0到1之间", dayType)
+		}
+		config.Demand.Calendar.Profiles[dayType] = profile
+	}
+
+	// 检查出行需求矩阵配置
+	if config.Demand.ODMatrix.FilePath != "" && config.Demand.Zones.FilePath == "" {
+		return fmt.Errorf("demand.odMatrix: 未指定小区文件(demand.zones.filePath)")
+	}
+
+	// 检查事故配置
+	for i, incident := range config.Incidents {
+		if err := incident.validate(); err != nil {
+			return fmt.Errorf("incidents[%d]: %v", i, err)
+		}
+	}
+
+	// 检查可变限速配置
+	for i, schedule := range config.SpeedLimits.Schedules {
+		if len(schedule.Cells) == 0 && len(schedule.Links) == 0 {
+			return fmt.Errorf("speedLimits.schedules[%d]: 未指定单元格或路段", i)
+		}
+		if schedule.StartStep < 0 || schedule.EndStep <= schedule.StartStep {
+			return fmt.Errorf("speedLimits.schedules[%d]: 生效时段无效: [%d, %d)", i, schedule.StartStep, schedule.EndStep)
+		}
+		if schedule.MaxSpeed <= 0 {
+			return fmt.Errorf("speedLimits.schedules[%d]: 限速必须为正", i)
+		}
+	}
+	if config.SpeedLimits.Rule.JamThreshold <= 0 || config.SpeedLimits.Rule.JamThreshold > 1 {
+		config.SpeedLimits.Rule.JamThreshold = 0.8 // 默认占用达到容量的80%视为拥堵
+	}
+	if config.SpeedLimits.Rule.UpstreamCells <= 0 {
+		config.SpeedLimits.Rule.UpstreamCells = 20 // 默认拥堵上游20个单元格(150米)
+	}
+	if config.SpeedLimits.Rule.ReducedSpeed <= 0 {
+		config.SpeedLimits.Rule.ReducedSpeed = 3 // 默认降低为3个单元格/时间步
+	}
+	if config.SpeedLimits.Rule.Interval <= 0 {
+		config.SpeedLimits.Rule.Interval = 40 // 默认每40个时间步(1分钟)更新一次
+	}
+
 	// 设置路径配置的默认值
 	if config.Path.PathMethod == "" {
 		config.Path.PathMethod = "shortest" // 默认使用最短路径
 	}
 
+	if config.Path.TravelTime.UpdateInterval <= 0 {
+		config.Path.TravelTime.UpdateInterval = 40 // 默认每40个时间步(1分钟)更新一次
+	}
+	if config.Path.TravelTime.Smoothing <= 0 || config.Path.TravelTime.Smoothing > 1 {
+		config.Path.TravelTime.Smoothing = 0.3 // 默认当前估计值权重0.3
+	}
+
 	if config.Path.KShortest.K <= 0 {
 		config.Path.KShortest.K = 3 // 默认计算3条最短路径
 	}
@@ -182,6 +758,52 @@ func LoadConfig(filename string) error {
 		config.Path.KShortest.LengthWeightFactor = 1.0 // 默认权重因子
 	}
 
+	if config.Path.KShortest.Dispersion <= 0 {
+		config.Path.KShortest.Dispersion = 0.1 // 默认离散参数
+	}
+	if config.Path.KShortest.LengthCoef < 0 || config.Path.KShortest.TimeCoef < 0 {
+		return fmt.Errorf("path.kShortest: 广义费用系数不能为负")
+	}
+	if config.Path.KShortest.LengthCoef == 0 && config.Path.KShortest.TimeCoef == 0 {
+		config.Path.KShortest.LengthCoef = 1.0 // 默认只考虑路径长度
+	}
+	if config.Path.KShortest.CommonalityFactor <= 0 {
+		config.Path.KShortest.CommonalityFactor = 1.0 // 默认共性因子系数
+	}
+	if config.Path.KShortest.PathSizeFactor <= 0 {
+		config.Path.KShortest.PathSizeFactor = 1.0 // 默认路径规模系数
+	}
+
+	// 设置动态重新规划路径的默认值
+	if config.Path.Reroute.Share < 0 || config.Path.Reroute.Share > 1 {
+		return fmt.Errorf("path.reroute: 份额必须在0到1之间")
+	}
+	if config.Path.Reroute.Interval < 0 || config.Path.Reroute.CongestionThreshold < 0 || config.Path.Reroute.Cooldown < 0 {
+		return fmt.Errorf("path.reroute: 间隔和拥堵阈值不能为负")
+	}
+	if config.Path.Reroute.Lookahead <= 0 {
+		config.Path.Reroute.Lookahead = 20 // 默认检查前方20个单元格(150米)
+	}
+	if config.Path.Reroute.Cooldown == 0 {
+		config.Path.Reroute.Cooldown = 40 // 默认两次重新规划至少间隔40个时间步(1分钟)
+	}
+
+	// 设置路径学习的默认值
+	if config.Path.Learning.Scope == "" {
+		config.Path.Learning.Scope = "none" // 默认不学习
+	}
+	switch config.Path.Learning.Scope {
+	case "none", "od", "driver":
+	default:
+		return fmt.Errorf("path.learning: 未知的记忆范围: %s", config.Path.Learning.Scope)
+	}
+	if config.Path.Learning.Smoothing <= 0 || config.Path.Learning.Smoothing > 1 {
+		config.Path.Learning.Smoothing = 0.5 // 默认当天行程时间权重0.5
+	}
+	if config.Path.Learning.Dispersion <= 0 {
+		config.Path.Learning.Dispersion = 0.05 // 默认离散参数
+	}
+
 	// 设置出行距离配置的默认值
 	// 默认启用距离限制
 	if config.TripDistance.MinDistMultiplier <= 0 {
@@ -192,15 +814,201 @@ func LoadConfig(filename string) error {
 		config.TripDistance.MaxDistMultiplier = 1.0 // 默认不缩放最大距离
 	}
 
+	// 设置经验出行距离分布的默认值
+	if config.TripDistance.Empirical.Kind == "" {
+		config.TripDistance.Empirical.Kind = "cdf"
+	}
+	if config.TripDistance.Empirical.Kind != "cdf" && config.TripDistance.Empirical.Kind != "pdf" {
+		return fmt.Errorf("tripDistance.empirical: 未知的分布类型: %s", config.TripDistance.Empirical.Kind)
+	}
+	if config.TripDistance.Empirical.Unit == "" {
+		config.TripDistance.Empirical.Unit = "mile"
+	}
+	if config.TripDistance.Empirical.Unit != "mile" && config.TripDistance.Empirical.Unit != "km" {
+		return fmt.Errorf("tripDistance.empirical: 未知的距离单位: %s", config.TripDistance.Empirical.Unit)
+	}
+	if config.TripDistance.Empirical.Tolerance <= 0 {
+		config.TripDistance.Empirical.Tolerance = 0.1 // 默认允许10%的误差
+	}
+	if config.TripDistance.Empirical.MaxAttempts <= 0 {
+		config.TripDistance.Empirical.MaxAttempts = 10
+	}
+
 	// 设置车辆轨迹记录间隔的默认值
 	if config.Vehicle.TraceInterval <= 0 {
 		config.Vehicle.TraceInterval = 1 // 默认每个时间步记录
 	}
 
+	// 设置驾驶模型的默认值
+	if len(config.Vehicle.DrivingModels) == 0 {
+		config.Vehicle.DrivingModels = map[string]DrivingModelConfig{
+			"default": {Model: "nasch"}, // 默认使用NaSch模型
+		}
+	}
+	for class, model := range config.Vehicle.DrivingModels {
+		if model.Model == "" {
+			model.Model = "nasch"
+		}
+		switch model.Model {
+		case "nasch", "vdr", "tt", "kkw":
+		default:
+			return fmt.Errorf("vehicle.drivingModels.%s: 未知的驾驶模型: %s", class, model.Model)
+		}
+		if model.SlowToStartProb < 0 || model.SlowToStartProb > 1 {
+			return fmt.Errorf("vehicle.drivingModels.%s: 慢启动概率必须在0到1之间", class)
+		}
+		if model.Model == "kkw" && model.SyncFactor <= 0 {
+			model.SyncFactor = 2.55 // Kerner等(2002)中的同步距离系数
+		}
+		config.Vehicle.DrivingModels[class] = model
+	}
+
+	// 设置车队组成的默认值，与原有的随机车辆参数一致
+	if len(config.Vehicle.Classes) == 0 {
+		config.Vehicle.Classes = []VehicleClassConfig{{
+			Name:            "car",
+			Share:           1.0,
+			Occupy:          1.0,
+			Acceleration:    [2]int{1, 3},
+			InitialVelocity: [2]int{1, 2},
+			SlowingProb:     [2]float64{0, 0.5},
+		}}
+	}
+	totalShare := 0.0
+	for i, class := range config.Vehicle.Classes {
+		if err := class.validate(); err != nil {
+			return fmt.Errorf("vehicle.classes[%d]: %v", i, err)
+		}
+		totalShare += class.Share
+	}
+	if totalShare <= 0 {
+		return fmt.Errorf("vehicle.classes: 份额之和必须为正")
+	}
+
+	// 检查出行链配置
+	totalShare = 0.0
+	for i, chain := range config.Vehicle.Tours.Chains {
+		if len(chain.Activities) < 2 {
+			return fmt.Errorf("vehicle.tours.chains[%d]: 活动链至少包含两个活动", i)
+		}
+		if chain.Share < 0 {
+			return fmt.Errorf("vehicle.tours.chains[%d]: 份额不能为负", i)
+		}
+		totalShare += chain.Share
+	}
+	if len(config.Vehicle.Tours.Chains) > 0 && totalShare <= 0 {
+		return fmt.Errorf("vehicle.tours.chains: 份额之和必须为正")
+	}
+	// 完成活动链后从最后一个活动开始新的活动链，必须有以该活动开始且份额为正的活动链
+	startShares := make(map[string]float64)
+	for _, chain := range config.Vehicle.Tours.Chains {
+		startShares[chain.Activities[0]] += chain.Share
+	}
+	for i, chain := range config.Vehicle.Tours.Chains {
+		last := chain.Activities[len(chain.Activities)-1]
+		if startShares[last] <= 0 {
+			return fmt.Errorf("vehicle.tours.chains[%d]: 活动链以%s结束，但没有以%s开始且份额为正的活动链", i, last, last)
+		}
+	}
+	for name, activity := range config.Vehicle.Tours.Activities {
+		if activity.Distribution == "" {
+			activity.Distribution = "fixed"
+		}
+		if err := activity.validate(); err != nil {
+			return fmt.Errorf("vehicle.tours.activities.%s: %v", name, err)
+		}
+		config.Vehicle.Tours.Activities[name] = activity
+	}
+
+	// 设置换道模型的默认值
+	if config.Vehicle.LaneChange.Rule == "" {
+		config.Vehicle.LaneChange.Rule = "symmetric" // 默认使用对称换道规则
+	}
+	if config.Vehicle.LaneChange.Probability <= 0 {
+		config.Vehicle.LaneChange.Probability = 1.0 // 满足条件即换道
+	}
+
 	globalConfig = config
 	return nil
 }
 
+// validate 检查配时方案的取值范围
+func (timing SignalTiming) validate() error {
+	if len(timing.IDs) == 0 {
+		return fmt.Errorf("未指定红绿灯或交叉口ID")
+	}
+	if timing.Cycle < 0 || timing.Offset < 0 {
+		return fmt.Errorf("周期和相位差不能为负")
+	}
+	if timing.Green != [2]int{} {
+		if timing.Green[0] < 0 || timing.Green[1] <= timing.Green[0] {
+			return fmt.Errorf("绿灯区间无效: %v", timing.Green)
+		}
+		if timing.Cycle > 0 && timing.Green[1] > timing.Cycle {
+			return fmt.Errorf("绿灯区间超出周期: %v", timing.Green)
+		}
+	}
+	for _, green := range timing.PhaseGreens {
+		if green <= 0 {
+			return fmt.Errorf("相位绿灯时长必须为正: %v", timing.PhaseGreens)
+		}
+	}
+	return nil
+}
+
+// validate 检查事故配置的取值范围
+func (incident IncidentConfig) validate() error {
+	if len(incident.Cells) == 0 && len(incident.Links) == 0 {
+		return fmt.Errorf("未指定单元格或路段")
+	}
+	if incident.StartStep < 0 || incident.Duration <= 0 {
+		return fmt.Errorf("开始时间步不能为负，持续时间必须为正")
+	}
+	if incident.CapacityReduction <= 0 || incident.CapacityReduction > 1 {
+		return fmt.Errorf("容量降低比例必须在(0, 1]之间")
+	}
+	return nil
+}
+
+// validate 检查活动停留时间分布的取值范围
+func (activity ActivityConfig) validate() error {
+	switch activity.Distribution {
+	case "fixed", "uniform", "normal", "exponential":
+	default:
+		return fmt.Errorf("未知的停留时间分布: %s", activity.Distribution)
+	}
+	if activity.Mean < 0 || activity.Std < 0 || activity.Min < 0 {
+		return fmt.Errorf("停留时间的均值、标准差和最短时间不能为负")
+	}
+	return nil
+}
+
+// validate 检查车辆类别的取值范围
+func (class VehicleClassConfig) validate() error {
+	if class.Name == "" {
+		return fmt.Errorf("类别名称不能为空")
+	}
+	if class.Share < 0 {
+		return fmt.Errorf("%s: 份额不能为负", class.Name)
+	}
+	if class.Occupy <= 0 || class.Occupy > 1 {
+		return fmt.Errorf("%s: 占用空间必须在(0, 1]之间，车辆不能超过一条车道的容量", class.Name)
+	}
+	if class.MaxSpeed < 0 {
+		return fmt.Errorf("%s: 最高速度不能为负", class.Name)
+	}
+	if class.Acceleration[0] < 1 || class.Acceleration[1] < class.Acceleration[0] {
+		return fmt.Errorf("%s: 加速度范围无效: %v", class.Name, class.Acceleration)
+	}
+	if class.InitialVelocity[0] < 0 || class.InitialVelocity[1] < class.InitialVelocity[0] {
+		return fmt.Errorf("%s: 初始速度范围无效: %v", class.Name, class.InitialVelocity)
+	}
+	if class.SlowingProb[0] < 0 || class.SlowingProb[1] > 1 || class.SlowingProb[1] < class.SlowingProb[0] {
+		return fmt.Errorf("%s: 随机减速概率范围无效: %v", class.Name, class.SlowingProb)
+	}
+	return nil
+}
+
 // GetConfig returns the global configuration instance
 func GetConfig() *Config {
 	return globalConfig
@@ -5,12 +5,21 @@ import "gonum.org/v1/gonum/graph"
 type Cell interface {
 	graph.Node
 	MaxSpeed() int
+	SetMaxSpeed(speed int)
 	Occupation() float64
 	Capacity() float64
+	ClosedLanes() int
+	SetClosedLanes(closed int)
+	Lanes() int
+	LaneOccupation(lane int) float64
+	LaneOf(v *Vehicle) (int, bool)
 	ListContainer() []*Vehicle
 	ListBuffer() []*Vehicle
 	Loadable(v *Vehicle) bool
+	LaneLoadable(v *Vehicle, lane int) bool
 	Load(v *Vehicle) (bool, error)
+	LoadAnyLane(v *Vehicle) (int, error)
+	SwitchLane(v *Vehicle, lane int) (bool, error)
 	Unload(v *Vehicle) (bool, error)
 	BufferLoad(v *Vehicle) bool
 	BufferUnload(v *Vehicle) (bool, error)
@@ -7,28 +7,44 @@ import (
 )
 
 // CommonCell 表示一个普通的单元格
+// 单元格可以包含多条车道，每条车道的容量为capacity/lanes，车道0为最右侧车道
 type CommonCell struct {
-	id         int64
-	speedLimit int
-	capacity   float64
-	occupation float64
-	container  map[*Vehicle]struct{}
-	buffer     *list.List
+	id             int64
+	speedLimit     int
+	capacity       float64
+	lanes          int
+	occupation     float64
+	laneOccupation []float64
+	closedLanes    int              // 关闭的车道数，事故等事件发生时从最右侧车道开始关闭
+	container      map[*Vehicle]int // 车辆及其所在车道
+	buffer         *list.List
 
 	// 使用RWMutex替代Mutex以提高并发读取性能
 	containerMux sync.RWMutex
 	bufferMux    sync.RWMutex
 }
 
-// NewCommonCell 创建一个新的普通单元格
+// NewCommonCell 创建一个新的单车道普通单元格
 func NewCommonCell(id int64, speed int, capacity float64) *CommonCell {
+	return NewMultiLaneCell(id, speed, capacity, 1)
+}
+
+// NewMultiLaneCell 创建一个新的多车道普通单元格
+// capacity为所有车道的总容量，按车道平均分配
+func NewMultiLaneCell(id int64, speed int, capacity float64, lanes int) *CommonCell {
+	if lanes <= 0 {
+		panic("lanes must be positive")
+	}
+
 	return &CommonCell{
-		id:         id,
-		speedLimit: speed,
-		capacity:   capacity,
-		occupation: 0,
-		container:  make(map[*Vehicle]struct{}, 10), // 预分配更合适的初始容量
-		buffer:     list.New(),
+		id:             id,
+		speedLimit:     speed,
+		capacity:       capacity,
+		lanes:          lanes,
+		occupation:     0,
+		laneOccupation: make([]float64, lanes),
+		container:      make(map[*Vehicle]int, 10), // 预分配更合适的初始容量
+		buffer:         list.New(),
 	}
 }
 
@@ -42,6 +58,15 @@ func (cell *CommonCell) MaxSpeed() int {
 	return cell.speedLimit
 }
 
+// SetMaxSpeed 设置单元格的速度限制，用于可变限速控制
+// 应在车辆移动之前串行调用，车辆在下一次加速时即受新的限速约束
+func (cell *CommonCell) SetMaxSpeed(speed int) {
+	if speed <= 0 {
+		panic("speed limit must be positive")
+	}
+	cell.speedLimit = speed
+}
+
 // Occupation 返回单元格的当前占用率
 func (cell *CommonCell) Occupation() float64 {
 	cell.containerMux.RLock()
@@ -54,6 +79,64 @@ func (cell *CommonCell) Capacity() float64 {
 	return cell.capacity
 }
 
+// Lanes 返回单元格的车道数
+func (cell *CommonCell) Lanes() int {
+	return cell.lanes
+}
+
+// SetClosedLanes 设置关闭的车道数，从最右侧车道(车道0)开始关闭，全部关闭时单元格无法进入
+// 已在关闭车道上的车辆可以继续驶离，新车辆不能进入或换道到关闭的车道
+func (cell *CommonCell) SetClosedLanes(closed int) {
+	if closed < 0 || closed > cell.lanes {
+		panic("closed lanes must be between 0 and lanes")
+	}
+	cell.containerMux.Lock()
+	defer cell.containerMux.Unlock()
+	cell.closedLanes = closed
+}
+
+// ClosedLanes 返回关闭的车道数
+func (cell *CommonCell) ClosedLanes() int {
+	cell.containerMux.RLock()
+	defer cell.containerMux.RUnlock()
+	return cell.closedLanes
+}
+
+// laneCapacity 返回指定车道的有效容量，关闭的车道容量为0，调用方需持有containerMux锁
+func (cell *CommonCell) laneCapacity(lane int) float64 {
+	if lane < cell.closedLanes {
+		return 0
+	}
+	return cell.capacity / float64(cell.lanes)
+}
+
+// clampLane 将车道编号限制在单元格的车道范围内
+// 车辆从多车道驶入少车道单元格时被挤入最左侧的剩余车道（车道缩减）
+func (cell *CommonCell) clampLane(lane int) int {
+	return max(min(lane, cell.lanes-1), 0)
+}
+
+// entryLane 返回车辆从指定车道驶入时进入的车道，关闭车道上的车辆被挤入最右侧的开放车道
+// 调用方需持有containerMux锁
+func (cell *CommonCell) entryLane(lane int) int {
+	return max(cell.clampLane(lane), min(cell.closedLanes, cell.lanes-1))
+}
+
+// LaneOccupation 返回指定车道的当前占用
+func (cell *CommonCell) LaneOccupation(lane int) float64 {
+	cell.containerMux.RLock()
+	defer cell.containerMux.RUnlock()
+	return cell.laneOccupation[cell.clampLane(lane)]
+}
+
+// LaneOf 返回车辆在单元格中所在的车道
+func (cell *CommonCell) LaneOf(vehicle *Vehicle) (int, bool) {
+	cell.containerMux.RLock()
+	defer cell.containerMux.RUnlock()
+	lane, ok := cell.container[vehicle]
+	return lane, ok
+}
+
 // ListContainer 返回单元格中的所有车辆
 func (cell *CommonCell) ListContainer() []*Vehicle {
 	cell.containerMux.RLock()
@@ -80,40 +163,102 @@ func (cell *CommonCell) ListBuffer() []*Vehicle {
 
 // ChangeToTrafficLightCell 将普通单元格转换为红绿灯单元格
 func (cell *CommonCell) ChangeToTrafficLightCell(interval int, truePhaseInterval [2]int) *TrafficLightCell {
-	return NewTrafficLightCell(cell.id, cell.speedLimit, cell.capacity, interval, truePhaseInterval)
+	light := NewTrafficLightCell(cell.id, cell.speedLimit, cell.capacity, interval, truePhaseInterval)
+	light.lanes = cell.lanes
+	light.laneOccupation = make([]float64, cell.lanes)
+	return light
 }
 
 // Loadable 检查单元格是否可以装载指定车辆
+// 车辆进入与其当前车道对应的车道，该车道关闭时进入最近的开放车道
 func (cell *CommonCell) Loadable(vehicle *Vehicle) bool {
 	cell.containerMux.RLock()
 	defer cell.containerMux.RUnlock()
-	return cell.occupation+vehicle.occupy <= cell.capacity
+	lane := cell.entryLane(vehicle.lane)
+	return cell.laneOccupation[lane]+vehicle.occupy <= cell.laneCapacity(lane)
+}
+
+// LaneLoadable 检查单元格的指定车道是否可以装载车辆
+func (cell *CommonCell) LaneLoadable(vehicle *Vehicle, lane int) bool {
+	cell.containerMux.RLock()
+	defer cell.containerMux.RUnlock()
+	lane = cell.clampLane(lane)
+	return cell.laneOccupation[lane]+vehicle.occupy <= cell.laneCapacity(lane)
 }
 
-// Load 将车辆装载到单元格中
+// Load 将车辆装载到单元格中与其当前车道对应的车道，该车道关闭时进入最近的开放车道
 func (cell *CommonCell) Load(vehicle *Vehicle) (bool, error) {
 	cell.containerMux.Lock()
 	defer cell.containerMux.Unlock()
 
-	if cell.occupation+vehicle.occupy > cell.capacity {
-		err := fmt.Errorf("cell %d current occupation %f, vehicle occupy %f, exceed capacity %f", cell.id, cell.occupation, vehicle.occupy, cell.capacity)
+	return cell.loadToLane(vehicle, cell.entryLane(vehicle.lane))
+}
+
+// LoadAnyLane 将车辆装载到占用最少的开放车道中，返回所在车道
+// 用于车辆从缓冲区进入路网
+func (cell *CommonCell) LoadAnyLane(vehicle *Vehicle) (int, error) {
+	cell.containerMux.Lock()
+	defer cell.containerMux.Unlock()
+
+	lane := cell.entryLane(0)
+	for i := lane + 1; i < cell.lanes; i++ {
+		if cell.laneOccupation[i] < cell.laneOccupation[lane] {
+			lane = i
+		}
+	}
+
+	if _, err := cell.loadToLane(vehicle, lane); err != nil {
+		return -1, err
+	}
+	return lane, nil
+}
+
+// loadToLane 将车辆装载到指定车道，调用方需持有containerMux写锁
+func (cell *CommonCell) loadToLane(vehicle *Vehicle, lane int) (bool, error) {
+	if cell.laneOccupation[lane]+vehicle.occupy > cell.laneCapacity(lane) {
+		err := fmt.Errorf("cell %d lane %d current occupation %f, vehicle occupy %f, exceed lane capacity %f", cell.id, lane, cell.laneOccupation[lane], vehicle.occupy, cell.laneCapacity(lane))
 		return false, err
 	}
-	cell.container[vehicle] = struct{}{}
+	cell.container[vehicle] = lane
+	cell.laneOccupation[lane] += vehicle.occupy
 	cell.occupation += vehicle.occupy
 	return true, nil
 }
 
+// SwitchLane 将单元格中的车辆移动到指定车道
+func (cell *CommonCell) SwitchLane(vehicle *Vehicle, lane int) (bool, error) {
+	cell.containerMux.Lock()
+	defer cell.containerMux.Unlock()
+
+	current, ok := cell.container[vehicle]
+	if !ok {
+		return false, fmt.Errorf("cell %d does not contain vehicle %d", cell.id, vehicle.Index())
+	}
+	if lane < 0 || lane >= cell.lanes {
+		return false, fmt.Errorf("cell %d has no lane %d", cell.id, lane)
+	}
+	if cell.laneOccupation[lane]+vehicle.occupy > cell.laneCapacity(lane) {
+		return false, fmt.Errorf("cell %d lane %d is full", cell.id, lane)
+	}
+
+	cell.laneOccupation[current] -= vehicle.occupy
+	cell.laneOccupation[lane] += vehicle.occupy
+	cell.container[vehicle] = lane
+	return true, nil
+}
+
 // Unload 从单元格中卸载车辆
 func (cell *CommonCell) Unload(vehicle *Vehicle) (bool, error) {
 	cell.containerMux.Lock()
 	defer cell.containerMux.Unlock()
 
-	if _, ok := cell.container[vehicle]; !ok {
+	lane, ok := cell.container[vehicle]
+	if !ok {
 		err := fmt.Errorf("cell %d does not contain vehicle %d", cell.id, vehicle.Index())
 		return false, err
 	}
 	delete(cell.container, vehicle)
+	cell.laneOccupation[lane] -= vehicle.occupy
 	cell.occupation -= vehicle.occupy
 	return true, nil
 }
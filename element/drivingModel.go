@@ -0,0 +1,197 @@
+package element
+
+import (
+	"math"
+	"math/rand/v2"
+	"simAndLearning/config"
+)
+
+// 元胞自动机驾驶模型
+const (
+	DRIVING_MODEL_NASCH = "nasch" // Nagel-Schreckenberg模型
+	DRIVING_MODEL_VDR   = "vdr"   // 速度相关随机慢化(VDR)模型
+	DRIVING_MODEL_TT    = "tt"    // Takayasu-Takayasu慢启动模型
+	DRIVING_MODEL_KKW   = "kkw"   // Kerner-Klenov-Wolf三相交通流模型
+)
+
+// 驾驶模型配置使用的车辆类别，车队组成中的类别未单独配置驾驶模型时依次使用以下类别的模型
+const (
+	VEHICLE_CLASS_DEFAULT   = "default"   // 默认类别
+	VEHICLE_CLASS_CLOSED    = "closed"    // 封闭车辆
+	VEHICLE_CLASS_SCHEDULED = "scheduled" // 按需求生成的车辆
+)
+
+// DrivingModel 表示决定车辆每个时间步速度的元胞自动机驾驶模型
+// NextVelocity在车辆持有自身锁时调用，可读取车辆的状态和前方路况，返回本时间步的速度
+type DrivingModel interface {
+	Name() string
+	NextVelocity(v *Vehicle) int
+}
+
+// DrivingModelParams 保存驾驶模型的参数
+type DrivingModelParams struct {
+	// 静止车辆的慢启动概率（VDR、TT、KKW）
+	SlowToStartProb float64
+	// KKW模型的同步距离系数k，同步距离D=d+k*v
+	SyncFactor float64
+}
+
+// NewDrivingModel 根据名称创建驾驶模型
+//
+// 参数:
+//   - name: 模型名称(DRIVING_MODEL_*)
+//   - params: 模型参数
+//
+// 返回:
+//   - DrivingModel: 创建的驾驶模型
+func NewDrivingModel(name string, params DrivingModelParams) DrivingModel {
+	if params.SlowToStartProb < 0 || params.SlowToStartProb > 1 {
+		panic("slow-to-start probability must be between 0 and 1")
+	}
+
+	switch name {
+	case DRIVING_MODEL_NASCH, "":
+		return naSchModel{}
+	case DRIVING_MODEL_VDR:
+		return vdrModel{slowToStartProb: params.SlowToStartProb}
+	case DRIVING_MODEL_TT:
+		return ttModel{slowToStartProb: params.SlowToStartProb}
+	case DRIVING_MODEL_KKW:
+		if params.SyncFactor <= 0 {
+			panic("sync factor must be positive")
+		}
+		return kkwModel{slowToStartProb: params.SlowToStartProb, syncFactor: params.SyncFactor}
+	}
+	panic("unknown driving model: " + name)
+}
+
+// drivingModelForClass 根据配置创建车辆的驾驶模型
+// 依次查找classes中第一个配置了驾驶模型的类别，均未配置时使用NaSch模型
+func drivingModelForClass(classes ...string) DrivingModel {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return naSchModel{}
+	}
+
+	for _, class := range classes {
+		if modelCfg, ok := cfg.Vehicle.DrivingModels[class]; ok {
+			return NewDrivingModel(modelCfg.Model, DrivingModelParams{
+				SlowToStartProb: modelCfg.SlowToStartProb,
+				SyncFactor:      modelCfg.SyncFactor,
+			})
+		}
+	}
+	return naSchModel{}
+}
+
+// naSchModel 经典Nagel-Schreckenberg模型: 加速、按间距减速、以slowingProb随机慢化
+type naSchModel struct{}
+
+func (naSchModel) Name() string {
+	return DRIVING_MODEL_NASCH
+}
+
+func (naSchModel) NextVelocity(v *Vehicle) int {
+	v.accelerate()
+	v.decelerate()
+	v.randomSlowing()
+	return v.velocity
+}
+
+// vdrModel 速度相关随机慢化模型(Barlovic等, 1998)
+// 静止车辆以slowToStartProb随机慢化，行驶车辆以slowingProb随机慢化，形成亚稳态和滞回现象
+type vdrModel struct {
+	slowToStartProb float64
+}
+
+func (vdrModel) Name() string {
+	return DRIVING_MODEL_VDR
+}
+
+func (m vdrModel) NextVelocity(v *Vehicle) int {
+	prob := v.slowingProb
+	if v.velocity == 0 {
+		prob = m.slowToStartProb
+	}
+
+	v.accelerate()
+	v.decelerate()
+	if rand.Float64() < prob {
+		v.velocity = max(v.velocity-1, 0)
+	}
+	return v.velocity
+}
+
+// ttModel Takayasu-Takayasu慢启动模型(Takayasu & Takayasu, 1993)
+// 静止车辆前方只有一个空元胞时，以1-slowToStartProb的概率启动；其余情况与NaSch相同
+type ttModel struct {
+	slowToStartProb float64
+}
+
+func (ttModel) Name() string {
+	return DRIVING_MODEL_TT
+}
+
+func (m ttModel) NextVelocity(v *Vehicle) int {
+	if v.velocity == 0 && v.calculateGap(2) == 1 && rand.Float64() < m.slowToStartProb {
+		return 0
+	}
+	v.accelerate()
+	v.decelerate()
+	v.randomSlowing()
+	return v.velocity
+}
+
+// kkwModel Kerner-Klenov-Wolf三相交通流元胞自动机模型(Kerner, Klenov & Wolf, 2002)
+// 前方间距大于同步距离D=d+k*v时自由加速；否则向前车速度靠拢(速度同步)，
+// 之后受间距限制并随机慢化，静止车辆使用慢启动概率
+type kkwModel struct {
+	slowToStartProb float64
+	syncFactor      float64
+}
+
+func (kkwModel) Name() string {
+	return DRIVING_MODEL_KKW
+}
+
+func (m kkwModel) NextVelocity(v *Vehicle) int {
+	cell, ok := v.pos.(Cell)
+	if !ok {
+		panic("pos is not a cell")
+	}
+	maxSpeed := v.speedLimitIn(cell)
+
+	// 同步距离(单元格)，车辆自身占用1个单元格
+	syncDistance := 1 + int(math.Ceil(m.syncFactor*float64(v.velocity)))
+	lookahead := max(syncDistance, v.velocity+v.acceleration)
+	gap := v.calculateGap(lookahead)
+
+	// 确定性规则
+	var next int
+	if gap > syncDistance-1 {
+		next = v.velocity + v.acceleration
+	} else {
+		leader := v.leaderVelocity(lookahead + 1)
+		switch {
+		case leader < 0 || leader > v.velocity:
+			next = v.velocity + v.acceleration
+		case leader < v.velocity:
+			next = v.velocity - v.acceleration
+		default:
+			next = v.velocity
+		}
+	}
+	next = max(min(next, maxSpeed, gap), 0)
+
+	// 随机慢化
+	prob := v.slowingProb
+	if v.velocity == 0 {
+		prob = m.slowToStartProb
+	}
+	if rand.Float64() < prob {
+		next = max(next-1, 0)
+	}
+
+	v.velocity = next
+	return v.velocity
+}
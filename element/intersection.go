@@ -0,0 +1,270 @@
+package element
+
+// 信号阶段
+const (
+	SIGNAL_STAGE_GREEN   = "green"  // 当前相位绿灯
+	SIGNAL_STAGE_AMBER   = "amber"  // 当前相位黄灯
+	SIGNAL_STAGE_ALL_RED = "allRed" // 全红清空
+)
+
+// SignalPhase 表示交叉口的一个信号相位
+// Lights为该相位放行的进口道红绿灯，Green为绿灯时长(时间步)
+type SignalPhase struct {
+	Lights []*TrafficLightCell
+	Green  int
+}
+
+// Intersection 表示一个由多个进口道红绿灯组成的信号交叉口
+// 交叉口按相位顺序依次放行，每个相位的绿灯之后依次为黄灯和全红清空时间，
+// 所有进口道共用同一个周期计数，保证各相位之间不会冲突
+// 黄灯期间车辆不再驶入交叉口，与红灯相同
+type Intersection struct {
+	id       int64
+	phases   []SignalPhase
+	amber    int
+	allRed   int
+	interval int
+	count    int
+
+	// 当前相位状态
+	phaseIndex int
+	stage      string
+
+	// control不为空时使用自适应控制，否则按固定配时运行
+	control *SignalControl
+}
+
+// NewIntersection 创建一个新的信号交叉口，并接管各相位红绿灯的控制
+//
+// 参数:
+//   - id: 交叉口ID，通常使用交叉口中心节点的ID
+//   - phases: 按放行顺序排列的相位
+//   - amber: 每个相位绿灯后的黄灯时长
+//   - allRed: 每个相位黄灯后的全红时长
+//
+// 返回:
+//   - *Intersection: 创建的交叉口
+func NewIntersection(id int64, phases []SignalPhase, amber int, allRed int) *Intersection {
+	// 验证参数合法性
+	if len(phases) == 0 {
+		panic("intersection must have at least one phase")
+	}
+	if amber < 0 || allRed < 0 {
+		panic("clearance time must not be negative")
+	}
+	for _, phase := range phases {
+		if phase.Green <= 0 {
+			panic("green time must be positive")
+		}
+		if len(phase.Lights) == 0 {
+			panic("phase must have at least one light")
+		}
+	}
+
+	in := &Intersection{
+		id:     id,
+		phases: phases,
+		amber:  amber,
+		allRed: allRed,
+		count:  0, // 初始化计数为0
+	}
+	in.interval = in.cycleLength()
+
+	for _, phase := range phases {
+		for _, light := range phase.Lights {
+			light.controller = in
+		}
+	}
+
+	return in
+}
+
+// cycleLength 计算所有相位的绿灯、黄灯和全红时长之和
+func (in *Intersection) cycleLength() int {
+	total := 0
+	for _, phase := range in.phases {
+		total += phase.Green + in.amber + in.allRed
+	}
+	return total
+}
+
+// Cycle 执行一个时间步，推进共享周期计数并更新各进口道红绿灯的相位
+// 设置了自适应控制时由控制策略决定相位切换
+func (in *Intersection) Cycle() {
+	in.count++
+	if in.count > in.interval {
+		in.count = 1
+	}
+	if in.control != nil {
+		in.control.step(in)
+		return
+	}
+	in.applyPhase()
+}
+
+// applyPhase 根据当前计数确定相位和阶段，并设置各红绿灯的相位
+func (in *Intersection) applyPhase() {
+	// 计数从1开始，position为当前周期内已经过的时间步
+	position := in.count - 1
+	for i, phase := range in.phases {
+		length := phase.Green + in.amber + in.allRed
+		if position < length {
+			in.phaseIndex = i
+			switch {
+			case position < phase.Green:
+				in.stage = SIGNAL_STAGE_GREEN
+			case position < phase.Green+in.amber:
+				in.stage = SIGNAL_STAGE_AMBER
+			default:
+				in.stage = SIGNAL_STAGE_ALL_RED
+			}
+			break
+		}
+		position -= length
+	}
+	in.setLights()
+}
+
+// setLights 按当前相位和阶段设置各进口道红绿灯
+func (in *Intersection) setLights() {
+	// 先将所有进口道置为红灯，再放行当前相位，同一红绿灯可属于多个相位
+	for _, phase := range in.phases {
+		for _, light := range phase.Lights {
+			light.phase = false
+		}
+	}
+	if in.stage == SIGNAL_STAGE_GREEN {
+		for _, light := range in.phases[in.phaseIndex].Lights {
+			light.phase = true
+		}
+	}
+}
+
+// ChangeInterval 按指定倍数改变交叉口的信号周期
+// 各相位绿灯时长按比例调整，黄灯和全红时长保持不变，当前计数按比例映射到新周期
+// 自适应控制下绿灯时长由控制策略决定，调整后的配时在恢复固定配时后生效
+func (in *Intersection) ChangeInterval(mul float64) {
+	if mul <= 0 {
+		panic("multiplier must be positive")
+	}
+
+	oldInterval := in.interval
+	for i := range in.phases {
+		in.phases[i].Green = max(int(float64(in.phases[i].Green)*mul), 1)
+	}
+	in.interval = in.cycleLength()
+
+	// 按比例映射计数，确保计数在有效范围内
+	count := int(float64(in.count) * float64(in.interval) / float64(oldInterval))
+	in.count = min(max(count, 1), in.interval)
+	if in.control == nil {
+		in.applyPhase()
+	}
+}
+
+// SetTiming 设置交叉口的配时方案
+// greens为各相位的绿灯时长，offset为新周期内已经过的时间步，仿真开始时即为相位差
+func (in *Intersection) SetTiming(greens []int, offset int) {
+	if len(greens) != len(in.phases) {
+		panic("greens must match phases")
+	}
+	for _, green := range greens {
+		if green <= 0 {
+			panic("green time must be positive")
+		}
+	}
+	for i, green := range greens {
+		in.phases[i].Green = green
+	}
+	in.interval = in.cycleLength()
+
+	if offset < 0 || offset >= in.interval {
+		panic("offset must be between 0 and interval-1")
+	}
+	in.count = offset
+	if in.count > 0 && in.control == nil {
+		in.applyPhase()
+	}
+}
+
+// GetClearanceTime 返回一个周期内所有相位的黄灯和全红时长之和
+func (in *Intersection) GetClearanceTime() int {
+	return len(in.phases) * (in.amber + in.allRed)
+}
+
+// SetCount 设置当前计数
+func (in *Intersection) SetCount(count int) {
+	if count <= 0 || count > in.interval {
+		panic("count must be between 1 and interval")
+	}
+	in.count = count
+	if in.control == nil {
+		in.applyPhase()
+	}
+}
+
+// SetControl 为交叉口设置自适应控制策略，传入nil时恢复固定配时
+// 自适应控制从第一个相位的绿灯开始运行
+func (in *Intersection) SetControl(control *SignalControl) {
+	in.control = control
+	if control == nil {
+		in.applyPhase()
+		return
+	}
+	control.reset(in)
+}
+
+// GetControl 返回交叉口的自适应控制策略，固定配时返回nil
+func (in *Intersection) GetControl() *SignalControl {
+	return in.control
+}
+
+// ID 返回交叉口ID
+func (in *Intersection) ID() int64 {
+	return in.id
+}
+
+// GetCount 返回当前计数
+func (in *Intersection) GetCount() int {
+	return in.count
+}
+
+// GetInterval 返回当前周期长度
+func (in *Intersection) GetInterval() int {
+	return in.interval
+}
+
+// GetPhases 返回交叉口的所有相位
+func (in *Intersection) GetPhases() []SignalPhase {
+	return in.phases
+}
+
+// GetAmberTime 返回黄灯时长
+func (in *Intersection) GetAmberTime() int {
+	return in.amber
+}
+
+// GetAllRedTime 返回全红时长
+func (in *Intersection) GetAllRedTime() int {
+	return in.allRed
+}
+
+// CurrentPhase 返回当前相位索引和所处阶段
+func (in *Intersection) CurrentPhase() (int, string) {
+	return in.phaseIndex, in.stage
+}
+
+// Lights 返回交叉口控制的所有红绿灯（去重）
+func (in *Intersection) Lights() []*TrafficLightCell {
+	seen := make(map[*TrafficLightCell]bool)
+	lights := make([]*TrafficLightCell, 0)
+	for _, phase := range in.phases {
+		for _, light := range phase.Lights {
+			if !seen[light] {
+				seen[light] = true
+				lights = append(lights, light)
+			}
+		}
+	}
+	return lights
+}
@@ -0,0 +1,170 @@
+package element
+
+import (
+	"gonum.org/v1/gonum/graph"
+)
+
+// 合流规则
+const (
+	MERGE_RULE_NONE     = "none"     // 不做处理，各进口道按协程顺序竞争
+	MERGE_RULE_PRIORITY = "priority" // 主路优先，次路在主路检测区无车时才能进入
+	MERGE_RULE_ZIPPER   = "zipper"   // 拉链式交替通行
+)
+
+// 分流规则
+const (
+	DIVERGE_RULE_LEGACY = "legacy" // 以固定概率0.8通过分流点
+	DIVERGE_RULE_SPEED  = "speed"  // 通过分流点的速度不超过分流速度
+)
+
+var (
+	// junctions 保存当前路网中的合流单元格，路网创建后设置，仿真过程中只读
+	junctions map[int64]*Junction = make(map[int64]*Junction)
+	// divergeRule 和 divergeSpeed 决定车辆通过分流单元格(出度>1)的方式
+	divergeRule  string = DIVERGE_RULE_LEGACY
+	divergeSpeed int    = 2
+)
+
+// SetJunctions 设置当前路网的合流单元格
+func SetJunctions(js map[int64]*Junction) {
+	junctions = js
+}
+
+// GetJunctions 返回当前路网的合流单元格
+func GetJunctions() map[int64]*Junction {
+	return junctions
+}
+
+// SetDivergeRule 设置分流规则和分流速度
+func SetDivergeRule(rule string, speed int) {
+	if rule != DIVERGE_RULE_LEGACY && rule != DIVERGE_RULE_SPEED {
+		panic("unknown diverge rule: " + rule)
+	}
+	if speed <= 0 {
+		panic("diverge speed must be positive")
+	}
+	divergeRule = rule
+	divergeSpeed = speed
+}
+
+// junctionAt 返回指定单元格对应的合流单元格，不是合流单元格时返回nil
+func junctionAt(id int64) *Junction {
+	return junctions[id]
+}
+
+// Junction 表示一个合流单元格(入度>1)
+// 每个时间步车辆移动之前调用Resolve，根据各进口道检测区内驶向该单元格的车辆
+// 按规则决定本时间步允许哪个进口道进入，车辆移动时只有被允许进口道的车辆可以驶入，
+// 从而使竞争由规则而不是协程执行顺序决定
+type Junction struct {
+	cell       Cell
+	rule       string
+	approaches []graph.Node // 进口道的上游单元格，按优先级从高到低排列
+	detectors  [][]Cell     // 每个进口道的检测单元格
+	lookahead  int          // 判断车辆是否驶向合流单元格的检查距离
+	granted    int64        // 本时间步允许进入的进口道上游单元格ID，-1表示没有竞争
+	last       int          // 拉链规则中上一次放行的进口道索引
+}
+
+// NewJunction 创建一个新的合流单元格
+//
+// 参数:
+//   - cell: 合流单元格
+//   - rule: 合流规则(MERGE_RULE_*)
+//   - approaches: 各进口道的上游单元格，按优先级从高到低排列
+//   - detectors: 各进口道的检测单元格
+//
+// 返回:
+//   - *Junction: 创建的合流单元格
+func NewJunction(cell Cell, rule string, approaches []graph.Node, detectors [][]Cell) *Junction {
+	if rule != MERGE_RULE_NONE && rule != MERGE_RULE_PRIORITY && rule != MERGE_RULE_ZIPPER {
+		panic("unknown merge rule: " + rule)
+	}
+	if len(approaches) != len(detectors) {
+		panic("detector approaches mismatch")
+	}
+
+	return &Junction{
+		cell:       cell,
+		rule:       rule,
+		approaches: approaches,
+		detectors:  detectors,
+		lookahead:  cell.MaxSpeed() + 1,
+		granted:    -1,
+		last:       -1,
+	}
+}
+
+// ID 返回合流单元格ID
+func (j *Junction) ID() int64 {
+	return j.cell.ID()
+}
+
+// Rule 返回合流规则
+func (j *Junction) Rule() string {
+	return j.rule
+}
+
+// Approaches 返回各进口道的上游单元格，按优先级从高到低排列
+func (j *Junction) Approaches() []graph.Node {
+	return j.approaches
+}
+
+// Resolve 决定本时间步允许进入的进口道，在车辆移动之前串行调用
+// 优先规则放行有需求的最高优先级进口道；拉链规则从上一次放行的进口道之后依次查找有需求的进口道；
+// 只有一个或没有进口道有需求时不限制进入
+func (j *Junction) Resolve() {
+	j.granted = -1
+	if j.rule == MERGE_RULE_NONE {
+		return
+	}
+
+	demand := make([]bool, len(j.approaches))
+	count := 0
+	for i := range j.approaches {
+		if j.hasDemand(i) {
+			demand[i] = true
+			count++
+		}
+	}
+	if count < 2 {
+		return
+	}
+
+	switch j.rule {
+	case MERGE_RULE_PRIORITY:
+		for i := range j.approaches {
+			if demand[i] {
+				j.granted = j.approaches[i].ID()
+				return
+			}
+		}
+	case MERGE_RULE_ZIPPER:
+		for k := 1; k <= len(j.approaches); k++ {
+			i := (j.last + k + len(j.approaches)) % len(j.approaches)
+			if demand[i] {
+				j.granted = j.approaches[i].ID()
+				j.last = i
+				return
+			}
+		}
+	}
+}
+
+// hasDemand 判断进口道检测区内是否有本时间步能够驶入合流单元格的车辆
+// 在红灯前排队的车辆不计入需求，避免红灯进口道占用放行权而阻挡绿灯进口道
+func (j *Junction) hasDemand(approach int) bool {
+	for _, cell := range j.detectors[approach] {
+		for _, vehicle := range cell.ListContainer() {
+			if vehicle.Heading(j.cell.ID(), j.lookahead) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Admits 判断本时间步是否允许从指定上游单元格驶入合流单元格
+func (j *Junction) Admits(from graph.Node) bool {
+	return j.granted < 0 || j.granted == from.ID()
+}
@@ -0,0 +1,122 @@
+package element
+
+import (
+	"math/rand/v2"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// 换道规则
+const (
+	LANE_CHANGE_NONE       = "none"       // 不换道
+	LANE_CHANGE_SYMMETRIC  = "symmetric"  // 对称换道规则
+	LANE_CHANGE_ASYMMETRIC = "asymmetric" // 非对称换道规则（靠右行驶，向左超车）
+)
+
+// changeLane 按照双车道NaSch换道规则(Rickert等, 1996)尝试换道
+//
+// 动机条件: 本车道前方空间不足以达到期望速度，且相邻车道前方空间更大
+// 安全条件: 目标车道在当前单元格有空位，且后方maxSpeed个单元格内目标车道没有车辆
+//
+// 对称规则下车辆可向任意一侧换道；非对称规则下只能向左侧超车，
+// 右侧车道前方空间足够时即回到右侧车道。满足条件时以laneChangeProb的概率换道
+func (v *Vehicle) changeLane() {
+	if v.laneChangeRule == "" || v.laneChangeRule == LANE_CHANGE_NONE {
+		return
+	}
+
+	cell, ok := v.pos.(Cell)
+	if !ok {
+		panic("pos is not a cell")
+	}
+	if cell.Lanes() < 2 {
+		return
+	}
+
+	desired := min(v.velocity+v.acceleration, v.speedLimitIn(cell))
+	ownGap := v.laneGap(v.lane, desired)
+
+	target := -1
+	switch v.laneChangeRule {
+	case LANE_CHANGE_SYMMETRIC:
+		if ownGap >= desired {
+			return
+		}
+		candidates := []int{v.lane - 1, v.lane + 1}
+		if rand.IntN(2) == 0 {
+			candidates[0], candidates[1] = candidates[1], candidates[0]
+		}
+		for _, lane := range candidates {
+			if lane >= 0 && lane < cell.Lanes() && v.laneGap(lane, desired) > ownGap && v.laneChangeSafe(cell, lane) {
+				target = lane
+				break
+			}
+		}
+
+	case LANE_CHANGE_ASYMMETRIC:
+		right, left := v.lane-1, v.lane+1
+		if right >= 0 && v.laneGap(right, desired) >= desired && v.laneChangeSafe(cell, right) {
+			target = right
+		} else if ownGap < desired && left < cell.Lanes() && v.laneGap(left, desired) > ownGap && v.laneChangeSafe(cell, left) {
+			target = left
+		}
+	}
+
+	if target < 0 || rand.Float64() >= v.laneChangeProb {
+		return
+	}
+
+	if ok, _ := cell.SwitchLane(v, target); ok {
+		v.lane = target
+	}
+}
+
+// laneGap 计算指定车道前方的可行驶单元格数，最多检查look个单元格
+func (v *Vehicle) laneGap(lane int, look int) int {
+	gap := 0
+	maxCheck := min(look, len(v.residualPath))
+	for i := 0; i < maxCheck; i++ {
+		cell, ok := v.residualPath[i].(Cell)
+		if !ok {
+			panic("node is not a cell")
+		}
+		if !cell.LaneLoadable(v, lane) {
+			break
+		}
+		gap++
+	}
+	return gap
+}
+
+// laneChangeSafe 检查换入目标车道是否安全
+// 要求目标车道在当前单元格有空位，且上游maxSpeed个单元格内该车道没有车辆
+func (v *Vehicle) laneChangeSafe(cell Cell, lane int) bool {
+	if !cell.LaneLoadable(v, lane) {
+		return false
+	}
+
+	depth := cell.MaxSpeed()
+	visited := map[int64]bool{cell.ID(): true}
+	frontier := []graph.Node{cell}
+	for d := 0; d < depth && len(frontier) > 0; d++ {
+		next := make([]graph.Node, 0, len(frontier))
+		for _, node := range frontier {
+			upstream := v.graph.To(node.ID())
+			for upstream.Next() {
+				prev := upstream.Node()
+				if visited[prev.ID()] {
+					continue
+				}
+				visited[prev.ID()] = true
+
+				if prevCell, ok := prev.(Cell); ok && prevCell.LaneOccupation(lane) > 0 {
+					return false
+				}
+				next = append(next, prev)
+			}
+		}
+		frontier = next
+	}
+
+	return true
+}
@@ -18,12 +18,11 @@ func getNextCellID() int64 {
 
 // Link 表示连接两个节点的链路，包含多个单元格
 type Link struct {
-	id         int64        // 链路ID
-	cells      []graph.Node // 链路包含的单元格
-	numCells   int          // 单元格数量
-	speedLimit int          // 速度限制
-	capacity   float64      // 每个单元格的容量
-	mu         sync.RWMutex // 用于保护并发访问
+	id       int64        // 链路ID
+	cells    []graph.Node // 链路包含的单元格
+	numCells int          // 单元格数量
+	capacity float64      // 每个单元格的容量
+	mu       sync.RWMutex // 用于保护并发访问
 }
 
 // NewLink 创建一个新的链路
@@ -39,11 +38,32 @@ func NewLink(id int64, numCells, speed int, capacity float64) *Link {
 	}
 
 	return &Link{
-		id:         id,
-		cells:      cells,
-		numCells:   numCells,
-		speedLimit: speed,
-		capacity:   capacity,
+		id:       id,
+		cells:    cells,
+		numCells: numCells,
+		capacity: capacity,
+	}
+}
+
+// NewLinkFromCells 使用路网中已有的单元格创建链路
+// 用于将路网生成器创建的单元格链作为一个路段进行统计，容量取第一个单元格的值
+func NewLinkFromCells(id int64, cells []graph.Node) *Link {
+	if len(cells) == 0 {
+		panic("cells must not be empty")
+	}
+	first, ok := cells[0].(Cell)
+	if !ok {
+		panic("node is not a cell")
+	}
+
+	linkCells := make([]graph.Node, len(cells))
+	copy(linkCells, cells)
+
+	return &Link{
+		id:       id,
+		cells:    linkCells,
+		numCells: len(linkCells),
+		capacity: first.Capacity(),
 	}
 }
 
@@ -95,6 +115,7 @@ func (l *Link) AddToNode(g *simple.DirectedGraph, node graph.Node) {
 }
 
 // Report 报告链路的状态信息
+// 速度限制取第一个单元格的当前限速，可变限速生效期间报告生效的限速
 // 返回：单元格数量，速度限制，容量，车辆数量，平均速度
 func (l *Link) Report() (int, int, float64, int, float64) {
 	l.mu.RLock()
@@ -111,7 +132,7 @@ func (l *Link) Report() (int, int, float64, int, float64) {
 	// 计算平均速度
 	var totalSpeed float64 = 0
 	for _, v := range allVehicle {
-		totalSpeed += float64(v.velocity)
+		totalSpeed += float64(v.ObservedVelocity())
 	}
 
 	numVehicle := len(allVehicle)
@@ -120,7 +141,7 @@ func (l *Link) Report() (int, int, float64, int, float64) {
 		averageSpeed = totalSpeed / float64(numVehicle)
 	}
 
-	return l.numCells, l.speedLimit, l.capacity, numVehicle, averageSpeed
+	return l.numCells, l.cells[0].(Cell).MaxSpeed(), l.capacity, numVehicle, averageSpeed
 }
 
 // GetCell 返回链路中指定索引的单元格
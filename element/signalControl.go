@@ -0,0 +1,201 @@
+package element
+
+// 信号控制方式
+const (
+	SIGNAL_CONTROL_FIXED        = "fixed"       // 固定配时
+	SIGNAL_CONTROL_ACTUATED     = "actuated"    // 感应控制（最小绿灯+间隙结束）
+	SIGNAL_CONTROL_MAX_PRESSURE = "maxPressure" // 最大压力控制
+)
+
+// SignalControl 表示交叉口的自适应信号控制策略
+// 每个时间步根据检测区内的实时排队决定是否结束当前绿灯以及下一个放行的相位
+//
+// 感应控制: 绿灯至少持续minGreen，之后若检测区连续gapTime个时间步没有车辆则结束绿灯，
+// 绿灯最长不超过maxGreen，相位按顺序轮换
+//
+// 最大压力控制(Varaiya, 2013): 相位压力为上游检测区车辆数减去下游检测区车辆数，
+// 绿灯满足minGreen后若有其他相位压力更大则切换到压力最大的相位，
+// 单相位交叉口在压力不为正时结束绿灯
+type SignalControl struct {
+	mode     string
+	minGreen int
+	maxGreen int
+	gapTime  int
+
+	// upstream和downstream为每个相位的上游和下游检测单元格
+	upstream   [][]Cell
+	downstream [][]Cell
+
+	// 运行状态
+	elapsed   int // 当前阶段已持续的时间步
+	gapCount  int // 检测区连续无车的时间步
+	nextPhase int // 清空结束后放行的相位
+}
+
+// NewSignalControl 创建一个新的自适应信号控制策略
+//
+// 参数:
+//   - mode: 控制方式(SIGNAL_CONTROL_ACTUATED或SIGNAL_CONTROL_MAX_PRESSURE)
+//   - minGreen: 最小绿灯时长
+//   - maxGreen: 最大绿灯时长
+//   - gapTime: 感应控制的间隙时长
+//   - upstream: 每个相位的上游检测单元格
+//   - downstream: 每个相位的下游检测单元格
+//
+// 返回:
+//   - *SignalControl: 创建的控制策略
+func NewSignalControl(mode string, minGreen, maxGreen, gapTime int, upstream, downstream [][]Cell) *SignalControl {
+	// 验证参数合法性
+	if mode != SIGNAL_CONTROL_ACTUATED && mode != SIGNAL_CONTROL_MAX_PRESSURE {
+		panic("unknown signal control mode: " + mode)
+	}
+	if minGreen <= 0 || maxGreen < minGreen {
+		panic("invalid green time range")
+	}
+	if gapTime <= 0 {
+		panic("gap time must be positive")
+	}
+	if len(upstream) != len(downstream) {
+		panic("detector phases mismatch")
+	}
+
+	return &SignalControl{
+		mode:       mode,
+		minGreen:   minGreen,
+		maxGreen:   maxGreen,
+		gapTime:    gapTime,
+		upstream:   upstream,
+		downstream: downstream,
+	}
+}
+
+// Mode 返回控制方式
+func (c *SignalControl) Mode() string {
+	return c.mode
+}
+
+// reset 从第一个相位的绿灯开始运行
+func (c *SignalControl) reset(in *Intersection) {
+	if len(c.upstream) != len(in.phases) {
+		panic("detector phases mismatch")
+	}
+	in.phaseIndex = 0
+	in.stage = SIGNAL_STAGE_GREEN
+	c.elapsed = 0
+	c.gapCount = 0
+	in.setLights()
+}
+
+// step 执行一个时间步的控制决策
+func (c *SignalControl) step(in *Intersection) {
+	c.elapsed++
+
+	switch in.stage {
+	case SIGNAL_STAGE_GREEN:
+		if c.endGreen(in) {
+			c.nextPhase = c.choosePhase(in)
+			c.enterStage(in, SIGNAL_STAGE_AMBER)
+		}
+	case SIGNAL_STAGE_AMBER:
+		if c.elapsed >= in.amber {
+			c.enterStage(in, SIGNAL_STAGE_ALL_RED)
+		}
+	case SIGNAL_STAGE_ALL_RED:
+		if c.elapsed >= in.allRed {
+			c.enterStage(in, SIGNAL_STAGE_GREEN)
+		}
+	}
+
+	in.setLights()
+}
+
+// enterStage 进入指定阶段，时长为0的清空阶段直接跳过
+func (c *SignalControl) enterStage(in *Intersection, stage string) {
+	c.elapsed = 0
+	switch stage {
+	case SIGNAL_STAGE_AMBER:
+		if in.amber == 0 {
+			c.enterStage(in, SIGNAL_STAGE_ALL_RED)
+			return
+		}
+	case SIGNAL_STAGE_ALL_RED:
+		if in.allRed == 0 {
+			c.enterStage(in, SIGNAL_STAGE_GREEN)
+			return
+		}
+	case SIGNAL_STAGE_GREEN:
+		in.phaseIndex = c.nextPhase
+		c.gapCount = 0
+	}
+	in.stage = stage
+}
+
+// endGreen 判断是否结束当前相位的绿灯
+func (c *SignalControl) endGreen(in *Intersection) bool {
+	current := in.phaseIndex
+
+	// 感应控制在整个绿灯期间统计检测区的连续空闲时间
+	if c.mode == SIGNAL_CONTROL_ACTUATED {
+		if countVehicles(c.upstream[current]) > 0 {
+			c.gapCount = 0
+		} else {
+			c.gapCount++
+		}
+	}
+
+	if c.elapsed < c.minGreen {
+		return false
+	}
+	if c.elapsed >= c.maxGreen {
+		return true
+	}
+
+	switch c.mode {
+	case SIGNAL_CONTROL_ACTUATED:
+		return c.gapCount >= c.gapTime
+	case SIGNAL_CONTROL_MAX_PRESSURE:
+		pressure := c.pressure(current)
+		if len(in.phases) == 1 {
+			return pressure <= 0
+		}
+		for i := range in.phases {
+			if i != current && c.pressure(i) > pressure {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// choosePhase 选择清空结束后放行的相位
+// 感应控制按顺序轮换，最大压力控制选择其他相位中压力最大的相位
+func (c *SignalControl) choosePhase(in *Intersection) int {
+	current := in.phaseIndex
+	next := (current + 1) % len(in.phases)
+	if c.mode != SIGNAL_CONTROL_MAX_PRESSURE || len(in.phases) == 1 {
+		return next
+	}
+
+	best, bestPressure := next, c.pressure(next)
+	for k := 2; k < len(in.phases); k++ {
+		i := (current + k) % len(in.phases)
+		if p := c.pressure(i); p > bestPressure {
+			best, bestPressure = i, p
+		}
+	}
+	return best
+}
+
+// pressure 计算相位的压力（上游车辆数-下游车辆数）
+func (c *SignalControl) pressure(phase int) int {
+	return countVehicles(c.upstream[phase]) - countVehicles(c.downstream[phase])
+}
+
+// countVehicles 统计检测单元格中的车辆数
+func countVehicles(cells []Cell) int {
+	count := 0
+	for _, cell := range cells {
+		count += len(cell.ListContainer())
+	}
+	return count
+}
@@ -9,10 +9,12 @@ type TrafficLightCell struct {
 	// truePhaseInterval规定计数器属于该范围内时相位为true
 	// interval表示一个完整周期的长度
 	// count是当前周期内的计数
+	// controller不为空时由所属交叉口统一控制相位
 	phase             bool
 	truePhaseInterval [2]int
 	interval          int
 	count             int
+	controller        *Intersection
 }
 
 // NewTrafficLightCell 创建一个新的红绿灯单元格
@@ -34,7 +36,12 @@ func NewTrafficLightCell(id int64, speed int, capacity float64, interval int, tr
 }
 
 // Cycle 执行一个红绿灯周期
+// 属于交叉口的红绿灯由交叉口统一推进，此处不做处理
 func (light *TrafficLightCell) Cycle() {
+	if light.controller != nil {
+		return
+	}
+
 	light.count++
 	if light.count > light.interval {
 		light.count = 1
@@ -50,18 +57,24 @@ func (light *TrafficLightCell) Cycle() {
 
 // Loadable 重写父类方法，考虑红绿灯状态
 func (light *TrafficLightCell) Loadable(vehicle *Vehicle) bool {
-	light.containerMux.RLock()
-	defer light.containerMux.RUnlock()
+	// 只有在绿灯状态且车道容量足够时才能通过
+	return light.phase && light.CommonCell.Loadable(vehicle)
+}
 
-	// 只有在绿灯状态且容量足够时才能通过
-	return light.phase && (light.occupation+vehicle.occupy <= light.capacity)
+// LaneLoadable 重写父类方法，考虑红绿灯状态
+func (light *TrafficLightCell) LaneLoadable(vehicle *Vehicle, lane int) bool {
+	return light.phase && light.CommonCell.LaneLoadable(vehicle, lane)
 }
 
 // ChangeInterval 按指定倍数改变红绿灯周期
+// 属于交叉口的红绿灯应通过Intersection.ChangeInterval调整，此处不做处理
 func (light *TrafficLightCell) ChangeInterval(mul float64) {
 	if mul <= 0 {
 		panic("multiplier must be positive")
 	}
+	if light.controller != nil {
+		return
+	}
 
 	// 按比例调整相关参数
 	newInterval := int(float64(light.interval) * mul)
@@ -88,6 +101,25 @@ func (light *TrafficLightCell) ChangeInterval(mul float64) {
 	}
 }
 
+// SetTiming 设置红绿灯的配时方案
+// offset为新周期内已经过的时间步，仿真开始时即为相位差，取值范围为[0, interval)
+func (light *TrafficLightCell) SetTiming(interval int, truePhaseInterval [2]int, offset int) {
+	// 验证参数合法性
+	if interval <= 0 {
+		panic("interval must be positive")
+	}
+	if truePhaseInterval[0] < 0 || truePhaseInterval[1] <= truePhaseInterval[0] || truePhaseInterval[1] > interval {
+		panic("invalid true phase interval")
+	}
+	if offset < 0 || offset >= interval {
+		panic("offset must be between 0 and interval-1")
+	}
+
+	light.interval = interval
+	light.truePhaseInterval = truePhaseInterval
+	light.count = offset
+}
+
 // SetCount 设置当前计数
 func (light *TrafficLightCell) SetCount(count int) {
 	if count <= 0 || count > light.interval {
@@ -96,6 +128,16 @@ func (light *TrafficLightCell) SetCount(count int) {
 	light.count = count
 }
 
+// GetCount 返回当前计数
+func (light *TrafficLightCell) GetCount() int {
+	return light.count
+}
+
+// GetController 返回控制该红绿灯的交叉口，独立红绿灯返回nil
+func (light *TrafficLightCell) GetController() *Intersection {
+	return light.controller
+}
+
 // GetPhase 返回当前相位状态
 func (light *TrafficLightCell) GetPhase() bool {
 	return light.phase
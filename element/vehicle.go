@@ -5,6 +5,7 @@ import (
 	"math/rand/v2"
 	"simAndLearning/config"
 	"sync"
+	"sync/atomic"
 
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
@@ -16,6 +17,7 @@ type Vehicle struct {
 	velocity            int                   // 当前速度
 	acceleration        int                   // 加速度
 	occupy              float64               // 占用空间
+	lane                int                   // 当前所在车道，0为最右侧车道
 	slowingProb         float64               // 随机减速概率
 	tag                 float64               // 车辆标签，用于随机化处理
 	flag                bool                  // 标记车辆是否是封闭车辆
@@ -28,11 +30,25 @@ type Vehicle struct {
 	residualPath        []graph.Node          // 剩余路径
 	pathlength          int                   // 路径长度
 	inTime              int                   // 进入系统时间
+	entryTime           int                   // 从缓冲区进入路网的时间
 	outTime             int                   // 离开系统时间
 	activiate           bool                  // 是否激活
 	trace               map[int]graph.Node    // 车辆轨迹记录，记录时间和对应位置
 	lastTraceRecordTime int                   // 上次记录轨迹的时间
 	traceInterval       int                   // 轨迹记录时间间隔
+	laneChangeRule      string                // 换道规则
+	laneChangeProb      float64               // 满足换道条件时的换道概率
+	drivingModel        DrivingModel          // 驾驶模型
+	class               string                // 车辆类别
+	maxSpeed            int                   // 车辆自身的最高速度，0表示仅受单元格限速约束
+	rerouting           bool                  // 是否可以在行驶途中重新规划路径
+	reroutes            int                   // 行驶途中重新规划路径的次数
+	lastRerouteTime     int                   // 上次重新规划路径的时间
+	originZone          int64                 // 起点所属的交通小区ID，-1表示不属于小区
+	destinationZone     int64                 // 终点所属的交通小区ID，-1表示不属于小区
+	tourID              int64                 // 所属出行链ID，-1表示不属于出行链
+	tourSeq             int                   // 在出行链中的行程序号，从1开始
+	observedVelocity    atomic.Int64          // 最近一次移动后的速度，供其他车辆无锁读取
 	mu                  sync.RWMutex          // 用于保护并发访问
 }
 
@@ -53,11 +69,20 @@ func NewVehicle(index int64, velocity, acceleration int, occupy, slowingProb flo
 
 	// 从配置文件中读取轨迹记录间隔
 	traceInterval := 1 // 默认值
+	laneChangeRule := LANE_CHANGE_NONE
+	laneChangeProb := 0.0
 	if config.GetConfig() != nil {
 		traceInterval = config.GetConfig().Vehicle.TraceInterval
+		laneChangeRule = config.GetConfig().Vehicle.LaneChange.Rule
+		laneChangeProb = config.GetConfig().Vehicle.LaneChange.Probability
 	}
 
-	return &Vehicle{
+	class := VEHICLE_CLASS_SCHEDULED
+	if flag {
+		class = VEHICLE_CLASS_CLOSED
+	}
+
+	vehicle := &Vehicle{
 		index:               index,
 		velocity:            velocity,
 		acceleration:        acceleration,
@@ -68,7 +93,15 @@ func NewVehicle(index int64, velocity, acceleration int, occupy, slowingProb flo
 		trace:               make(map[int]graph.Node),
 		lastTraceRecordTime: 0,
 		traceInterval:       traceInterval,
+		laneChangeRule:      laneChangeRule,
+		laneChangeProb:      laneChangeProb,
+		drivingModel:        drivingModelForClass(class, VEHICLE_CLASS_DEFAULT),
+		originZone:          -1,
+		destinationZone:     -1,
+		tourID:              -1,
 	}
+	vehicle.observedVelocity.Store(int64(velocity))
+	return vehicle
 }
 
 // Index 返回车辆ID
@@ -83,6 +116,129 @@ func (v *Vehicle) Velocity() int {
 	return v.velocity
 }
 
+// ObservedVelocity 返回车辆最近一次移动后的速度
+// 不获取车辆锁，可在其他车辆移动过程中读取前车速度
+func (v *Vehicle) ObservedVelocity() int {
+	return int(v.observedVelocity.Load())
+}
+
+// DrivingModel 返回车辆使用的驾驶模型
+func (v *Vehicle) DrivingModel() DrivingModel {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.drivingModel
+}
+
+// SetDrivingModel 设置车辆使用的驾驶模型
+func (v *Vehicle) SetDrivingModel(model DrivingModel) {
+	if model == nil {
+		panic("driving model must not be nil")
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.drivingModel = model
+}
+
+// SetClass 设置车辆类别和车辆自身的最高速度
+// 驾驶模型按类别、封闭/计划车辆、默认类别的顺序从配置中选择
+func (v *Vehicle) SetClass(class string, maxSpeed int) {
+	if maxSpeed < 0 {
+		panic("max speed must be non-negative")
+	}
+
+	flagClass := VEHICLE_CLASS_SCHEDULED
+	if v.flag {
+		flagClass = VEHICLE_CLASS_CLOSED
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.class = class
+	v.maxSpeed = maxSpeed
+	v.drivingModel = drivingModelForClass(class, flagClass, VEHICLE_CLASS_DEFAULT)
+}
+
+// Class 返回车辆类别
+func (v *Vehicle) Class() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.class
+}
+
+// MaxSpeed 返回车辆自身的最高速度，0表示仅受单元格限速约束
+func (v *Vehicle) MaxSpeed() int {
+	return v.maxSpeed
+}
+
+// speedLimitIn 返回车辆在指定单元格中的最高速度
+func (v *Vehicle) speedLimitIn(cell Cell) int {
+	if v.maxSpeed > 0 {
+		return min(cell.MaxSpeed(), v.maxSpeed)
+	}
+	return cell.MaxSpeed()
+}
+
+// SetRerouting 设置车辆是否可以在行驶途中重新规划路径
+func (v *Vehicle) SetRerouting(rerouting bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rerouting = rerouting
+}
+
+// Rerouting 返回车辆是否可以在行驶途中重新规划路径
+func (v *Vehicle) Rerouting() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.rerouting
+}
+
+// Reroutes 返回车辆在行驶途中重新规划路径的次数
+func (v *Vehicle) Reroutes() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.reroutes
+}
+
+// LastRerouteTime 返回车辆上次重新规划路径的时间，未重新规划过时返回进入系统时间
+func (v *Vehicle) LastRerouteTime() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.reroutes == 0 {
+		return v.inTime
+	}
+	return v.lastRerouteTime
+}
+
+// SetZones 设置起点和终点所属的交通小区ID，-1表示不属于小区
+func (v *Vehicle) SetZones(originZone, destinationZone int64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.originZone = originZone
+	v.destinationZone = destinationZone
+}
+
+// Zones 返回起点和终点所属的交通小区ID，-1表示不属于小区
+func (v *Vehicle) Zones() (int64, int64) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.originZone, v.destinationZone
+}
+
+// SetTour 设置车辆所属的出行链ID和在出行链中的行程序号
+func (v *Vehicle) SetTour(tourID int64, seq int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tourID = tourID
+	v.tourSeq = seq
+}
+
+// Tour 返回车辆所属的出行链ID和在出行链中的行程序号，不属于出行链时ID为-1
+func (v *Vehicle) Tour() (int64, int) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.tourID, v.tourSeq
+}
+
 // Acceleration 返回车辆加速度
 func (v *Vehicle) Acceleration() int {
 	return v.acceleration
@@ -98,6 +254,13 @@ func (v *Vehicle) Occupy() float64 {
 	return v.occupy
 }
 
+// Lane 返回车辆当前所在车道
+func (v *Vehicle) Lane() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.lane
+}
+
 // State 返回车辆当前状态
 func (v *Vehicle) State() int {
 	v.mu.RLock()
@@ -205,6 +368,80 @@ func (v *Vehicle) SetPath(path []graph.Node) (bool, error) {
 	return true, nil
 }
 
+// Reroute 从当前位置开始替换车辆的剩余路径
+// 新路径必须从当前位置开始并到达原终点，已行驶的路径保留在简化路径中
+func (v *Vehicle) Reroute(path []graph.Node, time int) (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.state != 4 {
+		return false, errors.New("vehicle not in system")
+	}
+
+	if len(path) < 2 {
+		return false, errors.New("path too short")
+	}
+
+	if path[0].ID() != v.pos.ID() {
+		return false, errors.New("path does not start from current position")
+	}
+
+	if path[len(path)-1].ID() != v.destination.ID() {
+		return false, errors.New("path does not end at destination")
+	}
+
+	// 展开路径中的链路，不包含当前位置
+	residualPath := make([]graph.Node, 0, len(path)*2)
+	for _, node := range path[1:] {
+		switch assertNode := node.(type) {
+		case Cell:
+			residualPath = append(residualPath, assertNode)
+		case *Link:
+			residualPath = append(residualPath, assertNode.Flat()...)
+		default:
+			return false, errors.New("node is not a cell or link")
+		}
+	}
+
+	// 保留简化路径中已行驶到当前位置的部分
+	simplePath := make([]graph.Node, 0, len(v.simplePath)+len(path))
+	for _, node := range v.simplePath {
+		if node.ID() == v.pos.ID() {
+			break
+		}
+		simplePath = append(simplePath, node)
+	}
+	simplePath = append(simplePath, path...)
+
+	v.pathlength += len(residualPath) - len(v.residualPath)
+	v.simplePath = simplePath
+	v.residualPath = residualPath
+	v.reroutes++
+	v.lastRerouteTime = time
+	return true, nil
+}
+
+// CongestionAhead 返回剩余路径前lookahead个单元格的平均拥堵程度(占用/容量)
+func (v *Vehicle) CongestionAhead(lookahead int) float64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	maxCheck := min(lookahead, len(v.residualPath))
+	if maxCheck == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for i := 0; i < maxCheck; i++ {
+		cell, ok := v.residualPath[i].(Cell)
+		if !ok {
+			panic("node is not a cell")
+		}
+		total += cell.Occupation() / cell.Capacity()
+	}
+	return total / float64(maxCheck)
+}
+
 // BufferIn 将车辆添加到起点的缓冲区
 func (v *Vehicle) BufferIn(inTime int) {
 	v.mu.Lock()
@@ -254,8 +491,9 @@ func (v *Vehicle) UpdateActiveState() bool {
 	return false
 }
 
-// SystemIn 将车辆从缓冲区移动到系统中
-func (v *Vehicle) SystemIn() {
+// SystemIn 将车辆从缓冲区移动到系统中，并记录进入路网的时间
+// 车辆进入起点单元格中占用最少的车道，若所有车道均已满则返回false，车辆留在缓冲区
+func (v *Vehicle) SystemIn(simTime int) bool {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
@@ -278,11 +516,17 @@ func (v *Vehicle) SystemIn() {
 	// 然后取消下面这行的注释并调整为正确的导入路径
 	// v.traceInterval = config.GetConfig().Vehicle.TraceInterval
 
+	lane, err := cell.LoadAnyLane(v)
+	if err != nil {
+		return false
+	}
 	cell.BufferUnload(v)
-	cell.Load(v)
+	v.lane = lane
 	v.pos = cell
 	v.residualPath = v.residualPath[1:]
+	v.entryTime = simTime
 	v.state = 4
+	return true
 }
 
 // SystemOut 将车辆从系统中移除
@@ -316,11 +560,15 @@ func (v *Vehicle) Move(time int) bool {
 		return false
 	}
 
-	// 纳格尔(Nagel-Schreckenberg)模型的四个步骤
+	// 移动结束后公开新的速度
+	defer func() { v.observedVelocity.Store(int64(v.velocity)) }()
+
+	// 多车道单元格中先进行换道
+	v.changeLane()
+
+	// 由驾驶模型决定本时间步的速度，目标单元格无法进入时重新决策
 	for {
-		v.accelerate()
-		v.decelerate()
-		v.randomSlowing()
+		v.drivingModel.NextVelocity(v)
 
 		if v.velocity == 0 {
 			// 如果traceInterval <= 0，RecordTraceWithInterval内部会直接返回不执行记录
@@ -354,6 +602,10 @@ func (v *Vehicle) Move(time int) bool {
 		currentCell.Unload(v)
 		targetCell.Load(v)
 		v.pos = targetCell
+		// 驶入车道数较少的单元格时车道编号随之缩减
+		if lane, ok := targetCell.LaneOf(v); ok {
+			v.lane = lane
+		}
 
 		// 更新路径
 		v.residualPath = v.residualPath[v.velocity:]
@@ -381,21 +633,23 @@ func (v *Vehicle) accelerate() {
 	if !ok {
 		panic("pos is not a cell")
 	}
-	v.velocity = min(v.velocity+v.acceleration, cell.MaxSpeed())
+	v.velocity = min(v.velocity+v.acceleration, v.speedLimitIn(cell))
 }
 
 // decelerate 车辆减速
 func (v *Vehicle) decelerate() {
-	gap := v.calculateGap()
+	gap := v.calculateGap(v.velocity)
 	v.velocity = min(v.velocity, gap)
 }
 
-// calculateGap 计算前方安全距离
-func (v *Vehicle) calculateGap() int {
+// calculateGap 计算前方安全距离，最多检查lookahead个单元格
+// 合流单元格只允许本时间步被放行的进口道驶入；分流单元格按分流规则限制通过
+func (v *Vehicle) calculateGap(lookahead int) int {
 	gap := 0
-	maxCheck := min(v.velocity, len(v.residualPath))
+	limit := lookahead
+	maxCheck := min(lookahead, len(v.residualPath))
 
-	for i := 0; i < maxCheck; i++ {
+	for i := 0; i < maxCheck && gap < limit; i++ {
 		node := v.residualPath[i]
 		cell, ok := node.(Cell)
 		if !ok {
@@ -406,18 +660,31 @@ func (v *Vehicle) calculateGap() int {
 			break
 		}
 
-		// 检查是否是交叉路口(出度>1)
-		fromNodes := v.graph.From(node.ID())
-		outDegree := 0
-		for fromNodes.Next() {
-			outDegree++
+		// 合流单元格由Resolve决定放行的进口道
+		if junction := junctionAt(node.ID()); junction != nil {
+			from := v.pos
+			if i > 0 {
+				from = v.residualPath[i-1]
+			}
+			if !junction.Admits(from) {
+				break
+			}
 		}
 
-		// 交叉路口有通过概率
-		if outDegree > 1 {
-			passProbability := 0.8
-			if rand.Float64() > passProbability {
-				return gap
+		// 检查是否是分流点(出度>1)
+		if v.graph.From(node.ID()).Len() > 1 {
+			switch divergeRule {
+			case DIVERGE_RULE_LEGACY:
+				// 分流点有通过概率
+				if rand.Float64() > 0.8 {
+					return gap
+				}
+			case DIVERGE_RULE_SPEED:
+				// 分流点在分流速度以内时最多以分流速度通过，否则停在分流点之前
+				if i >= divergeSpeed {
+					return gap
+				}
+				limit = min(limit, divergeSpeed)
 			}
 		}
 
@@ -427,6 +694,49 @@ func (v *Vehicle) calculateGap() int {
 	return gap
 }
 
+// Heading 判断车辆剩余路径的前lookahead个单元格中是否包含指定单元格，且途中没有红灯阻挡
+// 在红灯前排队的车辆本时间步无法到达该单元格，返回false
+func (v *Vehicle) Heading(id int64, lookahead int) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	for i := 0; i < min(lookahead, len(v.residualPath)); i++ {
+		if v.residualPath[i].ID() == id {
+			return true
+		}
+		if light, ok := v.residualPath[i].(*TrafficLightCell); ok && !light.GetPhase() {
+			return false
+		}
+	}
+	return false
+}
+
+// leaderVelocity 返回前方lookahead个单元格内第一个无法进入的单元格中同车道车辆的最低速度
+// 单元格无法进入但没有同车道车辆（如红灯）时视为静止障碍返回0，前方没有障碍时返回-1
+func (v *Vehicle) leaderVelocity(lookahead int) int {
+	maxCheck := min(lookahead, len(v.residualPath))
+	for i := 0; i < maxCheck; i++ {
+		cell, ok := v.residualPath[i].(Cell)
+		if !ok {
+			panic("node is not a cell")
+		}
+		if cell.Loadable(v) {
+			continue
+		}
+
+		leader := -1
+		for _, other := range cell.ListContainer() {
+			if lane, ok := cell.LaneOf(other); ok && lane == min(v.lane, cell.Lanes()-1) {
+				if speed := other.ObservedVelocity(); leader < 0 || speed < leader {
+					leader = speed
+				}
+			}
+		}
+		return max(leader, 0)
+	}
+	return -1
+}
+
 // randomSlowing 随机减速
 func (v *Vehicle) randomSlowing() {
 	if rand.Float64() < v.slowingProb {
@@ -476,6 +786,13 @@ func (v *Vehicle) InTime() int {
 	return v.inTime
 }
 
+// EntryTime 返回车辆从缓冲区进入路网的时间，不包括在起点缓冲区的等待
+func (v *Vehicle) EntryTime() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.entryTime
+}
+
 // 记录车辆轨迹
 func (v *Vehicle) recordTrace(time int) {
 	// 如果轨迹记录间隔小于等于0，不记录轨迹
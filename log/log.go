@@ -17,7 +17,7 @@ func LogEnvironment() {
 	log.Printf("Compiler Version: %s\n", runtime.Version())
 }
 
-func LogSimParameters(oneDayTimeSteps int, demandMultiplier, demandFixedNum, demandRandomDisRange float64, numClosedVehicle, simDay, initTrafficLightPhaseInterval, trafficLightChangeDay1 int, trafficLightMul1 float64, trafficLightChangeDay2 int, trafficLightMul2 float64) {
+func LogSimParameters(oneDayTimeSteps int, demandMultiplier, demandFixedNum, demandRandomDisRange float64, numClosedVehicle, simDay, initTrafficLightPhaseInterval int, trafficLightChangeDays []int, trafficLightMuls []float64) {
 	log.Println("--------------------Simulation Setting--------------------")
 	log.Printf("One Day Time Steps: %d\n", oneDayTimeSteps)
 	log.Printf("One Cell Length: %.2f\n", 7.5)
@@ -27,10 +27,10 @@ func LogSimParameters(oneDayTimeSteps int, demandMultiplier, demandFixedNum, dem
 	log.Printf("Closed Vehicle Num: %d\n", numClosedVehicle)
 	log.Printf("Simulation Days: %d\n", simDay)
 	log.Printf("Init Traffic Light True Phase Interval: %d\n", initTrafficLightPhaseInterval)
-	log.Printf("Traffic Light Change Day1: %d\n", trafficLightChangeDay1)
-	log.Printf("TrafficLight Mul1: %f\n", trafficLightMul1)
-	log.Printf("Traffic Light Change Day2: %d\n", trafficLightChangeDay2)
-	log.Printf("TrafficLight Mul2: %f\n", trafficLightMul2)
+	for i := range trafficLightChangeDays {
+		log.Printf("Traffic Light Change Day%d: %d\n", i+1, trafficLightChangeDays[i])
+		log.Printf("TrafficLight Mul%d: %f\n", i+1, trafficLightMuls[i])
+	}
 	log.Println("------------------------------------------------------------")
 }
 
@@ -39,8 +39,10 @@ func main() {
 	sysState := simulator.NewSystemState()
 	var demand []float64
 
-	// Initialize vehicles
-	simulator.InitFixedVehicle(cfg.Vehicle.NumClosedVehicle, g, nodes)
+	// Initialize vehicles; replayed closed vehicles are loaded with the first time step
+	if cfg.Demand.TripList.Mode != simulator.TRIP_LIST_REPLAY {
+		simulator.InitFixedVehicle(cfg.Vehicle.NumClosedVehicle, g, nodes)
+	}
 
 	// Start simulation
 	log.WriteLog("----------------------------------Simulation Start----------------------------------")
@@ -60,6 +62,12 @@ func initializeResources(cfg *config.Config, initTime string) (string, map[strin
 	log.LogEnvironment()
 
 	// Record simulation parameters
+	changeDays := make([]int, len(cfg.TrafficLight.Changes))
+	changeMuls := make([]float64, len(cfg.TrafficLight.Changes))
+	for i, change := range cfg.TrafficLight.Changes {
+		changeDays[i] = change.Day
+		changeMuls[i] = change.Multiplier
+	}
 	log.LogSimParameters(
 		cfg.Simulation.OneDayTimeSteps,
 		cfg.Demand.Multiplier,
@@ -68,20 +76,45 @@ func initializeResources(cfg *config.Config, initTime string) (string, map[strin
 		cfg.Vehicle.NumClosedVehicle,
 		cfg.Simulation.SimDay,
 		cfg.TrafficLight.InitPhaseInterval,
-		cfg.TrafficLight.Changes[0].Day,
-		cfg.TrafficLight.Changes[0].Multiplier,
-		cfg.TrafficLight.Changes[1].Day,
-		cfg.TrafficLight.Changes[1].Multiplier,
+		changeDays,
+		changeMuls,
 	)
 
 	// Record network parameters
 	log.WriteLog(fmt.Sprintf("Graph Type: %s", cfg.Graph.GraphType))
+	log.WriteLog(fmt.Sprintf("Road Lanes: %d", cfg.Graph.RoadLanes))
+	log.WriteLog(fmt.Sprintf("Lane Change Rule: %s, Probability: %.2f", cfg.Vehicle.LaneChange.Rule, cfg.Vehicle.LaneChange.Probability))
+	for _, class := range []string{"default", "closed", "scheduled"} {
+		if model, ok := cfg.Vehicle.DrivingModels[class]; ok {
+			log.WriteLog(fmt.Sprintf("Driving Model (%s): %s, SlowToStartProb: %.2f, SyncFactor: %.2f", class, model.Model, model.SlowToStartProb, model.SyncFactor))
+		}
+	}
+	for _, class := range cfg.Vehicle.Classes {
+		log.WriteLog(fmt.Sprintf("Vehicle Class %s: Share %.2f, Occupy %.2f, MaxSpeed %d, Acceleration %v, SlowingProb %v", class.Name, class.Share, class.Occupy, class.MaxSpeed, class.Acceleration, class.SlowingProb))
+	}
+	for _, chain := range cfg.Vehicle.Tours.Chains {
+		log.WriteLog(fmt.Sprintf("Tour Chain %v: Share %.2f", chain.Activities, chain.Share))
+	}
+	log.WriteLog(fmt.Sprintf("K-Shortest Strategy: %s, Dispersion: %.2f, Length Coef: %.2f, Time Coef: %.2f", cfg.Path.KShortest.SelectionStrategy, cfg.Path.KShortest.Dispersion, cfg.Path.KShortest.LengthCoef, cfg.Path.KShortest.TimeCoef))
+	log.WriteLog(fmt.Sprintf("Path Method: %s, Travel Time Update Interval: %d, Smoothing: %.2f", cfg.Path.PathMethod, cfg.Path.TravelTime.UpdateInterval, cfg.Path.TravelTime.Smoothing))
+	log.WriteLog(fmt.Sprintf("Route Learning Scope: %s, Smoothing: %.2f, Dispersion: %.2f", cfg.Path.Learning.Scope, cfg.Path.Learning.Smoothing, cfg.Path.Learning.Dispersion))
+	log.WriteLog(fmt.Sprintf("Reroute Share: %.2f, Interval: %d, Congestion Threshold: %.2f, Lookahead: %d, Cooldown: %d", cfg.Path.Reroute.Share, cfg.Path.Reroute.Interval, cfg.Path.Reroute.CongestionThreshold, cfg.Path.Reroute.Lookahead, cfg.Path.Reroute.Cooldown))
+	log.WriteLog(fmt.Sprintf("Merge Rule: %s, Diverge Rule: %s, Diverge Speed: %d", cfg.Graph.Junction.MergeRule, cfg.Graph.Junction.DivergeRule, cfg.Graph.Junction.DivergeSpeed))
+	log.WriteLog(fmt.Sprintf("Intersection Amber Time: %d, All-Red Time: %d", cfg.TrafficLight.AmberTime, cfg.TrafficLight.AllRedTime))
+	log.WriteLog(fmt.Sprintf("Signal Timings: %d, Time-of-Day Plans: %d", len(cfg.TrafficLight.Timings), len(cfg.TrafficLight.Plans)))
 	if cfg.Graph.GraphType == "cycle" {
 		log.WriteLog(fmt.Sprintf("Cycle Graph Cell Count: %d", cfg.Graph.CycleGraph.NumCell))
 		log.WriteLog(fmt.Sprintf("Cycle Graph Traffic Light Interval: %d", cfg.Graph.CycleGraph.LightIndexInterval))
 	} else if cfg.Graph.GraphType == "starRing" {
 		log.WriteLog(fmt.Sprintf("StarRing Graph Ring Cells Per Direction: %d", cfg.Graph.StarRingGraph.RingCellsPerDirection))
 		log.WriteLog(fmt.Sprintf("StarRing Graph Star Cells Per Direction: %d", cfg.Graph.StarRingGraph.StarCellsPerDirection))
+	} else if cfg.Graph.GraphType == "tntp" {
+		log.WriteLog(fmt.Sprintf("TNTP Graph File: %s", cfg.Graph.TNTPGraph.FilePath))
+		log.WriteLog(fmt.Sprintf("TNTP Graph Length Unit: %s", cfg.Graph.TNTPGraph.LengthUnit))
+	} else if cfg.Graph.GraphType == "osm" {
+		log.WriteLog(fmt.Sprintf("OSM Graph File: %s", cfg.Graph.OSMGraph.FilePath))
+	} else if cfg.Graph.GraphType == "file" {
+		log.WriteLog(fmt.Sprintf("Graph File: %s", cfg.Graph.FileGraph.FilePath))
 	}
 
 	log.WriteLog(fmt.Sprintf("Concurrent Volume in Vehicle Process: %d", runtime.GOMAXPROCS(0)))
@@ -93,15 +126,29 @@ func initializeResources(cfg *config.Config, initTime string) (string, map[strin
 	systemDataFile := fmt.Sprintf("./data/%s_%d_SystemData.csv", initTime, cfg.Vehicle.NumClosedVehicle)
 	vehicleDataFile := fmt.Sprintf("./data/%s_%d_VehicleData.csv", initTime, cfg.Vehicle.NumClosedVehicle)
 	traceDataFile := fmt.Sprintf("./data/%s_%d_TraceData.csv", initTime, cfg.Vehicle.NumClosedVehicle)
+	linkDataFile := fmt.Sprintf("./data/%s_%d_LinkData.csv", initTime, cfg.Vehicle.NumClosedVehicle)
 
 	recorder.InitSystemDataCSV(systemDataFile)
 	recorder.InitVehicleDataCSV(vehicleDataFile)
 	recorder.InitTraceDataCSV(traceDataFile)
+	recorder.InitLinkDataCSV(linkDataFile)
 
 	dataFiles := map[string]string{
 		"system":  systemDataFile,
 		"vehicle": vehicleDataFile,
 		"trace":   traceDataFile,
+		"link":    linkDataFile,
+	}
+
+	// Write generated trips to the trip list so the same demand can be replayed
+	if cfg.Demand.TripList.Mode == simulator.TRIP_LIST_WRITE {
+		tripListFile := cfg.Demand.TripList.FilePath
+		if tripListFile == "" {
+			tripListFile = fmt.Sprintf("./data/%s_%d_TripList.csv", initTime, cfg.Vehicle.NumClosedVehicle)
+		}
+		recorder.InitTripListCSV(tripListFile)
+		dataFiles["trips"] = tripListFile
+		log.WriteLog(fmt.Sprintf("Trip List Mode: %s, File: %s", cfg.Demand.TripList.Mode, tripListFile))
 	}
 
 	return logFile, dataFiles
@@ -159,6 +206,51 @@ func initializeSimulationEnvironment(cfg *config.Config, initTime string) (*simp
 		} else {
 			log.WriteLog(fmt.Sprintf("Grid graph saved to: %s", graphFilePath))
 		}
+	case "tntp":
+		// Import and save TNTP network
+		g, nodesMap, lights, err = simulator.SaveTNTPGraph(
+			cfg.Graph.TNTPGraph.FilePath,
+			cfg.Graph.TNTPGraph.LengthUnit,
+			cfg.Graph.TNTPGraph.LaneCapacity,
+			graphFilePath,
+		)
+		if g == nil {
+			panic(fmt.Sprintf("Failed to import TNTP graph: %v", err))
+		}
+		if err != nil {
+			log.WriteLog(fmt.Sprintf("Failed to save TNTP graph: %v", err))
+		} else {
+			log.WriteLog(fmt.Sprintf("TNTP graph saved to: %s", graphFilePath))
+		}
+	case "osm":
+		// Import and save OSM network
+		g, nodesMap, lights, err = simulator.SaveOSMGraph(
+			cfg.Graph.OSMGraph.FilePath,
+			cfg.TrafficLight.InitPhaseInterval,
+			graphFilePath,
+		)
+		if g == nil {
+			panic(fmt.Sprintf("Failed to import OSM graph: %v", err))
+		}
+		if err != nil {
+			log.WriteLog(fmt.Sprintf("Failed to save OSM graph: %v", err))
+		} else {
+			log.WriteLog(fmt.Sprintf("OSM graph saved to: %s", graphFilePath))
+		}
+	case "file":
+		// Reload a saved graph and save a copy for this run
+		g, nodesMap, lights, err = simulator.SaveFileGraph(
+			cfg.Graph.FileGraph.FilePath,
+			graphFilePath,
+		)
+		if g == nil {
+			panic(fmt.Sprintf("Failed to load graph file: %v", err))
+		}
+		if err != nil {
+			log.WriteLog(fmt.Sprintf("Failed to save loaded graph: %v", err))
+		} else {
+			log.WriteLog(fmt.Sprintf("Loaded graph saved to: %s", graphFilePath))
+		}
 	default:
 		// Default to cycle graph
 		log.WriteLog(fmt.Sprintf("Unknown graph type: %s, using default cycle graph", cfg.Graph.GraphType))
@@ -180,6 +272,64 @@ func initializeSimulationEnvironment(cfg *config.Config, initTime string) (*simp
 	log.WriteLog(fmt.Sprintf("Total Nodes: %d", numNodes))
 	log.WriteLog(fmt.Sprintf("Traffic Lights Count: %d", len(lights)))
 
+	// Apply signal control modes after the graph has been saved
+	adaptiveNum, err := simulator.ApplySignalControl(g, lights)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to apply signal control: %v", err))
+	}
+	log.WriteLog(fmt.Sprintf("Intersections Count: %d", len(simulator.GetIntersections())))
+	log.WriteLog(fmt.Sprintf("Links Count: %d", len(simulator.GetLinks())))
+	log.WriteLog(fmt.Sprintf("Merge Junctions Count: %d", len(element.GetJunctions())))
+	log.WriteLog(fmt.Sprintf("Signal Control Mode: %s, Adaptive Intersections: %d", cfg.TrafficLight.Control.Mode, adaptiveNum))
+
+	// Load traffic analysis zones and the zone-to-zone OD matrix
+	numZones, err := simulator.LoadZones(g)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load zones: %v", err))
+	}
+	if numZones > 0 {
+		log.WriteLog(fmt.Sprintf("Zones: %s, Count: %d", cfg.Demand.Zones.FilePath, numZones))
+	}
+	odMatrix, err := simulator.LoadODMatrix()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load OD matrix: %v", err))
+	}
+	if odMatrix != nil {
+		log.WriteLog(fmt.Sprintf("OD Matrix: %s, Total Trips: %.2f, Use Trip Total: %v", cfg.Demand.ODMatrix.FilePath, odMatrix.Total(), cfg.Demand.ODMatrix.UseTripTotal))
+	}
+
+	// Load the demand profile and resample it to the day length
+	profilePoints, err := simulator.LoadDemandProfile(cfg.Simulation.OneDayTimeSteps)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load demand profile: %v", err))
+	}
+	log.WriteLog(fmt.Sprintf("Demand Profile: %s, Points: %d, Interpolation: %s", cfg.Demand.Profile.FilePath, profilePoints, cfg.Demand.Profile.Interpolation))
+
+	// Load the empirical trip length distribution
+	tripLengths, err := simulator.LoadTripLengthDistribution()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load trip length distribution: %v", err))
+	}
+	if tripLengths != nil {
+		log.WriteLog(fmt.Sprintf("Trip Length Distribution: %s, Mean: %.2f %s, Tolerance: %.2f", cfg.TripDistance.Empirical.FilePath, tripLengths.Mean(), cfg.TripDistance.Empirical.Unit, cfg.TripDistance.Empirical.Tolerance))
+	}
+
+	// Load the trip list to replay instead of generating trips
+	numTrips, err := simulator.LoadTripList(g)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load trip list: %v", err))
+	}
+	if cfg.Demand.TripList.Mode == simulator.TRIP_LIST_REPLAY {
+		log.WriteLog(fmt.Sprintf("Trip List Mode: %s, File: %s, Trips: %d, Use Recorded Paths: %v", cfg.Demand.TripList.Mode, cfg.Demand.TripList.FilePath, numTrips, cfg.Demand.TripList.UseRecordedPaths))
+	}
+
+	// Resolve incident cells and links on the created graph
+	if err := simulator.InitIncidents(g); err != nil {
+		panic(fmt.Sprintf("Failed to initialize incidents: %v", err))
+	}
+	log.WriteLog(fmt.Sprintf("Incidents Count: %d", len(cfg.Incidents)))
+	log.WriteLog(fmt.Sprintf("Speed Limit Schedules: %d, Jam Rule Enabled: %v", len(cfg.SpeedLimits.Schedules), cfg.SpeedLimits.Rule.Enabled))
+
 	// Convert map to slice for easier processing
 	nodes := make([]graph.Node, 0, numNodes)
 	// Calculate total lanes
@@ -229,50 +379,75 @@ func runSimulation(cfg *config.Config, g *simple.DirectedGraph, nodes []graph.No
 	dataFiles map[string]string) {
 
 	simDaySteps := cfg.Simulation.SimDay * cfg.Simulation.OneDayTimeSteps
+	scheduler := simulator.NewSignalScheduler(lights)
+	speedLimits, err := simulator.NewSpeedLimitController(g, nodes)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize speed limits: %v", err))
+	}
 
 	// Main simulation loop
 	for timeStep := 0; timeStep < simDaySteps; timeStep++ {
 		timeOfDay := timeStep % cfg.Simulation.OneDayTimeSteps
 		currentDay := timeStep/cfg.Simulation.OneDayTimeSteps + 1
 
-		// Update demand distribution at the start of each day
-		if timeOfDay == 0 {
-			*demand = simulator.AdjustDemand(
-				cfg.Demand.Multiplier,
-				cfg.Demand.FixedNum,
-				cfg.Demand.DayRandomDisRange,
-			)
+		// Update route learning memory with the previous day's trips
+		if timeOfDay == 0 && timeStep > 0 {
+			updateDailyStats(cfg, currentDay-1)
 		}
 
-		// Check for traffic light cycle changes
-		for _, change := range cfg.TrafficLight.Changes {
-			if currentDay == change.Day && timeOfDay == 0 {
-				for _, light := range lights {
-					light.ChangeInterval(change.Multiplier)
-				}
-				log.WriteLog(fmt.Sprintf("TrafficLight Interval Changed: Multiplier - %.2f", change.Multiplier))
-			}
+		// Update demand distribution at the start of each day from the demand calendar
+		if timeOfDay == 0 {
+			var dayType string
+			dayType, *demand = simulator.DayDemand(currentDay)
+			log.WriteLog(fmt.Sprintf("Day %d Type: %s", currentDay, dayType))
 		}
 
-		// Generate and process vehicles
-		generateNum := simulator.GetGenerateVehicleCount(timeOfDay, *demand, cfg.Demand.RandomDisRange)
-		simulator.GenerateScheduleVehicle(timeStep, generateNum, g, nodes)
+		// Switch signal timing plans; new timings take effect when each signal finishes its cycle
+		scheduler.Update(currentDay, timeOfDay, timeStep)
+
+		// Generate and process vehicles, or replay them from the trip list
+		if cfg.Demand.TripList.Mode == simulator.TRIP_LIST_REPLAY {
+			simulator.ReplayTrips(timeStep, g)
+		} else {
+			generateNum := simulator.GetGenerateVehicleCount(timeOfDay, *demand, cfg.Demand.RandomDisRange)
+			simulator.GenerateScheduleVehicle(timeStep, generateNum, g, nodes)
+		}
 
 		// Traffic light cycle
+		for _, intersection := range simulator.GetIntersections() {
+			intersection.Cycle()
+		}
 		for _, light := range lights {
 			light.Cycle()
 		}
 
+		// Start and clear incidents
+		simulator.UpdateIncidents(timeStep)
+
+		// Update variable speed limits
+		speedLimits.Update(timeStep, timeOfDay)
+
 		// Process vehicle movement
 		simulator.VehicleProcess(runtime.GOMAXPROCS(0), timeStep, g)
 
+		// Update cell travel times for congestion-aware routing
+		if timeStep%cfg.Path.TravelTime.UpdateInterval == 0 {
+			utils.UpdateTravelTimes(nodes, cfg.Path.TravelTime.Smoothing)
+		}
+
 		// Update system state
 		sysState.Update(nodes, numNodes, avgLane)
 		sysState.RecordData(timeStep)
 
+		// Sample link data at intervals
+		if timeOfDay%cfg.Logging.IntervalRecordLinkData == 0 {
+			simulator.RecordLinkData(timeStep)
+		}
+
 		// Log at intervals
 		if timeOfDay%cfg.Logging.IntervalWriteToLog == 0 {
 			sysState.LogStatus(currentDay, timeOfDay)
+			simulator.WriteLinkData(dataFiles)
 		}
 
 		// Write system and vehicle data at intervals
@@ -281,6 +456,18 @@ func runSimulation(cfg *config.Config, g *simple.DirectedGraph, nodes []graph.No
 		}
 	}
 
+	// Fold the last day's trips into route learning memory
+	updateDailyStats(cfg, cfg.Simulation.SimDay)
+
 	// Perform final data write at simulation end to ensure all data is written
 	simulator.WriteData(dataFiles)
 }
+
+// Update route learning memory and log daily statistics for the finished day
+func updateDailyStats(cfg *config.Config, day int) {
+	stats := simulator.UpdateRouteLearning()
+	log.WriteLog(fmt.Sprintf("Day %d Route Learning: Trips %d, Relative Gap %.4f, Known Routes %d", day, stats.Trips, stats.RelativeGap, stats.KnownRoutes))
+	if trips, mean, ks := simulator.TripLengthFit(); trips > 0 {
+		log.WriteLog(fmt.Sprintf("Day %d Trip Length Fit: Trips %d, Mean %.2f %s, KS Distance %.4f", day, trips, mean, cfg.TripDistance.Empirical.Unit, ks))
+	}
+}
@@ -60,7 +60,11 @@ func WriteToLinkDataCSV(filename string, links []*element.Link) {
 		return
 	}
 	var linksData [][]string
-	for link, data := range linkDataCache {
+	for _, link := range links {
+		data, ok := linkDataCache[link]
+		if !ok {
+			continue
+		}
 		simTime0, simTime1, numCell, speedLim, capacity, avgNumVehicle, avgAverageSpeed, avgDensity := avgLinkData(data)
 		linksData = append(linksData, formatLinkDataOutput(link.ID(), simTime0, simTime1, numCell, speedLim, capacity, avgNumVehicle, avgAverageSpeed, avgDensity))
 	}
@@ -11,18 +11,19 @@ var (
 	systemDataMutex sync.Mutex = sync.Mutex{}
 )
 
-func RecordSystemData(simTime int, numVehicleGenerated, numVehiclesActive, numVehiclesWaiting, numVehicleCompleted int64, averageSpeed, vehicleDensity float64) {
+func RecordSystemData(simTime int, numVehicleGenerated, numVehiclesActive, numVehiclesWaiting, numVehicleCompleted int64, averageSpeed, vehicleDensity float64, activeIncidents int) {
 	systemDataMutex.Lock()
 	defer systemDataMutex.Unlock()
-	systemDataCache = append(systemDataCache, formatSystemState(simTime, numVehicleGenerated, numVehiclesActive, numVehiclesWaiting, numVehicleCompleted, averageSpeed, vehicleDensity))
+	systemDataCache = append(systemDataCache, formatSystemState(simTime, numVehicleGenerated, numVehiclesActive, numVehiclesWaiting, numVehicleCompleted, averageSpeed, vehicleDensity, activeIncidents))
 }
 
-func formatSystemState(simTime int, numVehicleGenerated, numVehiclesActive, numVehiclesWaiting, numVehicleCompleted int64, averageSpeed, vehicleDensity float64) []string {
+func formatSystemState(simTime int, numVehicleGenerated, numVehiclesActive, numVehiclesWaiting, numVehicleCompleted int64, averageSpeed, vehicleDensity float64, activeIncidents int) []string {
 	timeOfDay := simTime % 57600
 	day := simTime/57600 + 1
 	return []string{
 		strconv.Itoa(simTime),
 		strconv.Itoa(day),                          // 当前天数
+		getDayType(simTime),                        // 当天的日类型
 		strconv.Itoa(timeOfDay),                    // 时间步
 		strconv.FormatInt(numVehicleGenerated, 10), // 道路车辆数量
 		strconv.FormatInt(numVehiclesActive, 10),   // 道路车辆数量
@@ -30,12 +31,13 @@ func formatSystemState(simTime int, numVehicleGenerated, numVehiclesActive, numV
 		strconv.FormatInt(numVehicleCompleted, 10), // 道路车辆数量
 		fmt.Sprintf("%.4f", averageSpeed),          // 平均速度
 		fmt.Sprintf("%.4f", vehicleDensity),        // 车辆密度
+		strconv.Itoa(activeIncidents),              // 正在发生的事故数量
 	}
 }
 
 func InitSystemDataCSV(filename string) {
 	header := []string{
-		"SimTime", "Day", "TimeOfDay", "NumVehicleGenerated", "NumVehiclesActive", "NumVehiclesWaiting", "NumVehicleCompleted", "AverageSpeed", "VehicleDensity",
+		"SimTime", "Day", "DayType", "TimeOfDay", "NumVehicleGenerated", "NumVehiclesActive", "NumVehiclesWaiting", "NumVehicleCompleted", "AverageSpeed", "VehicleDensity", "ActiveIncidents",
 	}
 	initializeCSV(filename, header)
 }
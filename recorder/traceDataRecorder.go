@@ -15,6 +15,10 @@ var (
 	traceDataCacheByDay map[int][][]string = make(map[int][][]string)
 	traceDataMutex      sync.Mutex         = sync.Mutex{}
 	oneDayTimeSteps     int                = 57600 // 一天的时间步数
+
+	// 各模拟日的日类型，key为天数
+	dayTypes      map[int]string = make(map[int]string)
+	dayTypesMutex sync.RWMutex
 )
 
 // SetOneDayTimeSteps 设置一天的时间步数
@@ -24,6 +28,20 @@ func SetOneDayTimeSteps(steps int) {
 	}
 }
 
+// SetDayType 设置模拟日的日类型，写入系统数据和车辆数据
+func SetDayType(day int, dayType string) {
+	dayTypesMutex.Lock()
+	defer dayTypesMutex.Unlock()
+	dayTypes[day] = dayType
+}
+
+// getDayType 根据时间步获取当天的日类型
+func getDayType(timeStep int) string {
+	dayTypesMutex.RLock()
+	defer dayTypesMutex.RUnlock()
+	return dayTypes[getDay(timeStep)]
+}
+
 // getDay 根据时间步获取天数
 func getDay(timeStep int) int {
 	return timeStep/oneDayTimeSteps + 1
@@ -0,0 +1,60 @@
+package recorder
+
+import (
+	"simAndLearning/element"
+	"strconv"
+	"sync"
+)
+
+var (
+	tripListCache [][]string = make([][]string, 0)
+	tripListMutex sync.Mutex = sync.Mutex{}
+)
+
+// RecordTrip 记录生成的行程，包括出发时间步、车辆参数、起终点和选择的路径，用于回放
+// seq为该车辆的第几次行程，dwell为封闭车辆出发前在起点的计划停留时间
+func RecordTrip(vehicle *element.Vehicle, departure, seq, dwell int) {
+	tripListMutex.Lock()
+	defer tripListMutex.Unlock()
+	tripListCache = append(tripListCache, getTripData(vehicle, departure, seq, dwell))
+}
+
+func getTripData(vehicle *element.Vehicle, departure, seq, dwell int) []string {
+	tourID, tourSeq := vehicle.Tour()
+	return []string{
+		strconv.Itoa(departure),                                 // 出发时间步
+		strconv.FormatInt(vehicle.Index(), 10),                  // 车辆 ID
+		strconv.Itoa(seq),                                       // 车辆的第几次行程
+		strconv.FormatBool(vehicle.Flag()),                      // 是否为封闭系统车辆
+		vehicle.Class(),                                         // 车辆类别
+		strconv.Itoa(vehicle.MaxSpeed()),                        // 车辆自身的最高速度
+		strconv.Itoa(vehicle.Velocity()),                        // 初始速度
+		strconv.Itoa(vehicle.Acceleration()),                    // 车辆加速度
+		strconv.FormatFloat(vehicle.Occupy(), 'g', -1, 64),      // 占用空间
+		strconv.FormatFloat(vehicle.SlowingProb(), 'g', -1, 64), // 减速概率，保留全部精度
+		strconv.FormatBool(vehicle.Rerouting()),                 // 是否可以在途中重新规划路径
+		strconv.Itoa(dwell),                                     // 出发前的计划停留时间
+		strconv.FormatInt(tourID, 10),                           // 出行链 ID
+		strconv.Itoa(tourSeq),                                   // 在出行链中的行程序号
+		strconv.FormatInt(vehicle.Origin().ID(), 10),            // 起点 ID
+		strconv.FormatInt(vehicle.Destination().ID(), 10),       // 终点 ID
+		formatSimplePath(vehicle.GetPath()),                     // 选择的路径
+	}
+}
+
+func InitTripListCSV(filename string) {
+	header := []string{
+		"Departure", "Vehicle ID", "Trip Seq", "ClosedVehicle", "Class", "MaxSpeed", "Velocity", "Acceleration", "Occupy", "SlowingPro", "Rerouting", "Dwell", "Tour ID", "Tour Seq", "Origin", "Destination", "Path",
+	}
+	initializeCSV(filename, header)
+}
+
+func WriteToTripListCSV(filename string) {
+	tripListMutex.Lock()
+	defer tripListMutex.Unlock()
+	if len(tripListCache) == 0 {
+		return
+	}
+	appendToCSV(filename, tripListCache)
+	tripListCache = make([][]string, 0)
+}
@@ -32,24 +32,33 @@ func getVehicleData(vehicle *element.Vehicle) []string {
 	// 起终点ID
 	originId := vehicle.Origin().ID()
 	destinationId := vehicle.Destination().ID()
+	originZone, destinationZone := vehicle.Zones()
+	tourID, tourSeq := vehicle.Tour()
 	pathlength := vehicle.PathLength()
 
 	// 获取路径
 	simplePath := formatSimplePath(vehicle.GetPath())
 
 	return []string{
-		strconv.FormatInt(idx, 10),           // 新增的唯一索引
-		strconv.FormatInt(index, 10),         // 车辆 ID
-		strconv.Itoa(acceleration),           // 车辆加速度
-		fmt.Sprintf("%.4f", slowingProb),     // 减速概率
-		strconv.FormatInt(originId, 10),      // 起点 ID
-		strconv.FormatInt(destinationId, 10), // 终点 ID
-		strconv.Itoa(inTime),                 // 进入系统时间
-		strconv.Itoa(outTime),                // 到达时间
-		fmt.Sprintf("%.4f", tag),             // 标签
-		strconv.FormatBool(flag),             // 是否为封闭系统车辆
-		strconv.Itoa(pathlength),             // 路径长度（元胞数）
-		simplePath,                           // 车辆路径
+		strconv.FormatInt(idx, 10),             // 新增的唯一索引
+		strconv.FormatInt(index, 10),           // 车辆 ID
+		strconv.Itoa(acceleration),             // 车辆加速度
+		fmt.Sprintf("%.4f", slowingProb),       // 减速概率
+		strconv.FormatInt(originId, 10),        // 起点 ID
+		strconv.FormatInt(destinationId, 10),   // 终点 ID
+		strconv.FormatInt(originZone, 10),      // 起点小区 ID，-1表示不属于小区
+		strconv.FormatInt(destinationZone, 10), // 终点小区 ID，-1表示不属于小区
+		strconv.Itoa(inTime),                   // 进入系统时间
+		getDayType(inTime),                     // 进入系统当天的日类型
+		strconv.Itoa(outTime),                  // 到达时间
+		fmt.Sprintf("%.4f", tag),               // 标签
+		strconv.FormatBool(flag),               // 是否为封闭系统车辆
+		vehicle.Class(),                        // 车辆类别
+		strconv.Itoa(pathlength),               // 路径长度（元胞数）
+		strconv.Itoa(vehicle.Reroutes()),       // 途中重新规划路径的次数
+		strconv.FormatInt(tourID, 10),          // 出行链 ID，-1表示不属于出行链
+		strconv.Itoa(tourSeq),                  // 在出行链中的行程序号
+		simplePath,                             // 车辆路径
 	}
 }
 
@@ -69,7 +78,7 @@ func formatSimplePath(path []graph.Node) string {
 
 func InitVehicleDataCSV(filename string) {
 	header := []string{
-		"Trip ID", "Vehicle ID", "Acceleration", "SlowingPro", "Origin", "Destination", "In Time", "Arrival Time", "Tag", "ClosedVehicle", "PathLength", "Path",
+		"Trip ID", "Vehicle ID", "Acceleration", "SlowingPro", "Origin", "Destination", "Origin Zone", "Destination Zone", "In Time", "Day Type", "Arrival Time", "Tag", "ClosedVehicle", "Class", "PathLength", "Reroutes", "Tour ID", "Tour Seq", "Path",
 	}
 	initializeCSV(filename, header)
 }
@@ -0,0 +1,63 @@
+package simulator
+
+import (
+	"simAndLearning/config"
+	"simAndLearning/recorder"
+)
+
+// 默认日类型，未配置需求日历时每天都是工作日
+const DAY_TYPE_WEEKDAY = "weekday"
+
+// dayTypeDemand 保存需求日历中配置了需求曲线文件的日类型的需求曲线，已重采样到一天的时间步数
+var dayTypeDemand map[string][]float64 = make(map[string][]float64)
+
+// DayType 返回模拟日的日类型
+// 指定了日类型的模拟日优先，其余按周期循环，未配置需求日历时返回"weekday"
+//
+// 参数:
+//   - day: 模拟日，从1开始
+func DayType(day int) string {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return DAY_TYPE_WEEKDAY
+	}
+	calendar := cfg.Demand.Calendar
+
+	for _, d := range calendar.Days {
+		if d.Day == day {
+			return d.Type
+		}
+	}
+	if len(calendar.Week) > 0 {
+		return calendar.Week[(day-1)%len(calendar.Week)]
+	}
+	return DAY_TYPE_WEEKDAY
+}
+
+// DayDemand 按模拟日的日类型生成当天各时间步的需求，并通知记录器当天的日类型
+// 需求曲线、乘数和随机波动范围取日类型的配置，未配置的日类型使用默认需求曲线和demand中的配置
+//
+// 参数:
+//   - day: 模拟日，从1开始
+//
+// 返回:
+//   - string: 日类型
+//   - []float64: 当天各时间步的需求
+func DayDemand(day int) (string, []float64) {
+	cfg := config.GetConfig()
+	dayType := DayType(day)
+	recorder.SetDayType(day, dayType)
+
+	profile := rawDemand
+	if demand, ok := dayTypeDemand[dayType]; ok {
+		profile = demand
+	}
+
+	multiplier, spread := 1.0, cfg.Demand.DayRandomDisRange
+	if dayProfile, ok := cfg.Demand.Calendar.Profiles[dayType]; ok {
+		multiplier, spread = dayProfile.Multiplier, *dayProfile.Spread
+	}
+
+	multiplier *= EffectiveDemandMultiplier(cfg.Demand.Multiplier, profile)
+	return dayType, AdjustDemand(profile, multiplier, cfg.Demand.FixedNum, spread)
+}
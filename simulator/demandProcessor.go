@@ -2,21 +2,106 @@ package simulator
 
 import (
 	"encoding/csv"
+	"fmt"
 	"log"
 	"math"
 	"os"
-	"path/filepath"
+	"simAndLearning/config"
 	"strconv"
 
 	"math/rand/v2"
 )
 
-// 交通需求原始数据，从CSV文件中读取
-var rawDemand []float64 = readDemandCSV()
+// 需求曲线的插值方法
+const (
+	DEMAND_INTERPOLATION_LINEAR = "linear" // 在相邻时段之间线性插值
+	DEMAND_INTERPOLATION_STEP   = "step"   // 取所在时段的值
+)
+
+// 交通需求原始数据，由LoadDemandProfile从需求曲线文件读取并重采样到一天的时间步数
+var rawDemand []float64
+
+// LoadDemandProfile 按配置读取需求曲线文件和需求日历中各日类型的需求曲线文件，并将其重采样到一天的时间步数
+// 文件可以是任意时间分辨率(如每小时、每15分钟或每个时间步)，各值表示对应时段占一天需求的份额，
+// 重采样后的总和与文件中的总和相同，即一天的总需求不随分辨率改变
+//
+// 参数:
+//   - oneDayTimeSteps: 一天的时间步数
+//
+// 返回:
+//   - int: 默认需求曲线文件中的时段数
+//   - error: 如果文件不存在或无法解析，返回错误
+func LoadDemandProfile(oneDayTimeSteps int) (int, error) {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return 0, fmt.Errorf("未加载配置")
+	}
+
+	profile, err := readDemandCSV(cfg.Demand.Profile.FilePath)
+	if err != nil {
+		return 0, err
+	}
+	rawDemand = resampleDemand(profile, oneDayTimeSteps, cfg.Demand.Profile.Interpolation)
+
+	dayTypeDemand = make(map[string][]float64)
+	for dayType, dayProfile := range cfg.Demand.Calendar.Profiles {
+		if dayProfile.FilePath == "" {
+			continue
+		}
+		profile, err := readDemandCSV(dayProfile.FilePath)
+		if err != nil {
+			return 0, fmt.Errorf("日类型 %s: %v", dayType, err)
+		}
+		dayTypeDemand[dayType] = resampleDemand(profile, oneDayTimeSteps, cfg.Demand.Profile.Interpolation)
+	}
+
+	return len(profile), nil
+}
+
+// resampleDemand 将需求曲线重采样到指定的时间步数
+// 每个值位于所在时段的中点，时间步取其中点处的值，一天首尾相接；
+// "linear"在相邻时段之间线性插值，"step"取所在时段的值。重采样后按比例缩放，使总和保持不变
+func resampleDemand(profile []float64, steps int, interpolation string) []float64 {
+	if len(profile) == steps {
+		return profile
+	}
+
+	n := len(profile)
+	resampled := make([]float64, steps)
+	for i := range resampled {
+		// 时间步中点在需求曲线中的位置，以时段为单位
+		pos := (float64(i) + 0.5) * float64(n) / float64(steps)
+		if interpolation == DEMAND_INTERPOLATION_STEP {
+			resampled[i] = profile[min(int(pos), n-1)]
+			continue
+		}
+
+		// 相对于时段中点的位置
+		pos -= 0.5
+		lower := int(math.Floor(pos))
+		frac := pos - float64(lower)
+		resampled[i] = profile[(lower%n+n)%n]*(1-frac) + profile[(lower+1)%n]*frac
+	}
+
+	sum, resampledSum := 0.0, 0.0
+	for _, d := range profile {
+		sum += d
+	}
+	for _, d := range resampled {
+		resampledSum += d
+	}
+	if resampledSum > 0 {
+		for i := range resampled {
+			resampled[i] *= sum / resampledSum
+		}
+	}
+	return resampled
+}
 
 // AdjustDemand 调整原始需求数据
 //
 // 参数:
+//   - profile: 原始需求曲线
 //   - A: 需求乘数
 //   - B: 需求偏移量
 //   - randomDis: 随机波动范围 (0-1)
@@ -26,20 +111,20 @@ var rawDemand []float64 = readDemandCSV()
 //
 // 公式: adjusted = (raw * A + B) * (1 + random_factor)
 // 其中 random_factor 在 [-randomDis, +randomDis] 范围内
-func AdjustDemand(A, B, randomDis float64) []float64 {
+func AdjustDemand(profile []float64, A, B, randomDis float64) []float64 {
 	// 验证参数
 	if randomDis < 0 || randomDis > 1 {
 		log.Printf("Warning: randomDis should be between 0 and 1, got %f", randomDis)
 		randomDis = math.Max(0, math.Min(1, randomDis)) // 限制在 0-1 范围内
 	}
 
-	adjustedDemand := make([]float64, len(rawDemand))
+	adjustedDemand := make([]float64, len(profile))
 
 	// 生成随机因子，范围在 [1-randomDis, 1+randomDis]
 	randomFactor := 1 + (rand.Float64()*2*randomDis - randomDis)
 
 	// 为每个时段调整需求
-	for i, d := range rawDemand {
+	for i, d := range profile {
 		adjustedDemand[i] = (d*A + B) * randomFactor
 
 		// 确保调整后的需求非负
@@ -108,43 +193,22 @@ func GetGenerateVehicleCount(timeOfDay int, dayDemandList []float64, randomDis f
 
 // readDemandCSV 从CSV文件读取交通需求分布数据
 //
+// 参数:
+//   - filename: 需求曲线文件路径
+//
 // 返回:
 //   - []float64: 交通需求数据列表
+//   - error: 如果文件不存在、没有数据或包含无效的需求值，返回错误
 //
 // 文件格式:
 //
 //	第一行为标题
 //	之后每行包含时段ID和对应的需求值
-func readDemandCSV() []float64 {
-	// 设置文件路径
-	filename := "./resources/DemandTimeDistribution_Smoothed.csv"
-
-	// 使用相对路径查找文件
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		// 尝试查找当前目录和上级目录
-		dirs := []string{".", "..", "../..", "../../.."}
-		found := false
-
-		for _, dir := range dirs {
-			path := filepath.Join(dir, "resources", "DemandTimeDistribution_Smoothed.csv")
-			if _, err := os.Stat(path); err == nil {
-				filename = path
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			log.Printf("Warning: Demand data file not found at %s, using empty demand list", filename)
-			return []float64{}
-		}
-	}
-
+func readDemandCSV(filename string) ([]float64, error) {
 	// 打开文件
 	file, err := os.Open(filename)
 	if err != nil {
-		log.Printf("Failed to open demand file: %s", err)
-		return []float64{} // 返回空列表而不是崩溃
+		return nil, fmt.Errorf("无法打开需求曲线文件: %v", err)
 	}
 	defer file.Close()
 
@@ -152,14 +216,12 @@ func readDemandCSV() []float64 {
 	reader := csv.NewReader(file)
 	records, err := reader.ReadAll()
 	if err != nil {
-		log.Printf("Failed to read CSV: %s", err)
-		return []float64{} // 返回空列表而不是崩溃
+		return nil, fmt.Errorf("读取需求曲线文件 %s 失败: %v", filename, err)
 	}
 
 	// 检查文件格式
 	if len(records) < 2 {
-		log.Printf("Demand data file has insufficient data")
-		return []float64{}
+		return nil, fmt.Errorf("需求曲线文件 %s 中没有数据", filename)
 	}
 
 	// 解析需求数据
@@ -167,20 +229,17 @@ func readDemandCSV() []float64 {
 	for i, record := range records[1:] {
 		// 确保记录包含足够的字段
 		if len(record) < 2 {
-			log.Printf("Warning: Record at line %d has insufficient fields", i+2)
-			continue
+			return nil, fmt.Errorf("%s 第%d行字段不足", filename, i+2)
 		}
 
 		// 解析概率值
 		pro, err := strconv.ParseFloat(record[1], 64)
-		if err != nil {
-			log.Printf("Warning: Failed to parse probability at line %d: %s", i+2, err)
-			continue
+		if err != nil || pro < 0 {
+			return nil, fmt.Errorf("%s 第%d行需求值无效: %s", filename, i+2, record[1])
 		}
 
 		demand = append(demand, pro)
 	}
 
-	log.Printf("Loaded %d demand data points from %s", len(demand), filename)
-	return demand
+	return demand, nil
 }
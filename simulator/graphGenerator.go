@@ -6,6 +6,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"simAndLearning/config"
 	"simAndLearning/element"
 	"simAndLearning/utils"
 
@@ -15,6 +16,21 @@ import (
 	"gonum.org/v1/gonum/graph/simple"
 )
 
+// roadLanes 返回合成路网中道路单元格的车道数
+func roadLanes() int {
+	cfg := config.GetConfig()
+	if cfg == nil || cfg.Graph.RoadLanes <= 0 {
+		return 1
+	}
+	return cfg.Graph.RoadLanes
+}
+
+// newRoadCell 创建合成路网中的道路单元格，每条车道容量为1
+func newRoadCell(id int64, speed int) *element.CommonCell {
+	lanes := roadLanes()
+	return element.NewMultiLaneCell(id, speed, float64(lanes), lanes)
+}
+
 // CreateCycleGraph 创建一个环形路网图
 //
 // 参数:
@@ -44,6 +60,7 @@ func CreateCycleGraph(cellNum int, trafficLightInterval int, initInterval int) (
 	// 创建存储节点的映射
 	nodes := make(map[int64]graph.Node, cellNum)
 	lights := make(map[int64]*element.TrafficLightCell)
+	resetIntersections()
 
 	// 创建所有节点（单元格）
 	trafficLightRatioCount := 0
@@ -65,10 +82,10 @@ func CreateCycleGraph(cellNum int, trafficLightInterval int, initInterval int) (
 			phaseInterval := [2]int{0, greenPhaseEnd}
 
 			// 创建红绿灯单元格
-			light := element.NewTrafficLightCell(
-				int64(i),      // 单元格ID
-				5,             // 最大速度
-				1.0,           // 容量
+			light := newRoadCell(
+				int64(i), // 单元格ID
+				5,        // 最大速度
+			).ChangeToTrafficLightCell(
 				initInterval,  // 周期长度
 				phaseInterval, // 相位区间
 			)
@@ -86,10 +103,9 @@ func CreateCycleGraph(cellNum int, trafficLightInterval int, initInterval int) (
 			trafficLightRatioCount++
 		} else {
 			// 创建普通单元格
-			node = element.NewCommonCell(
+			node = newRoadCell(
 				int64(i), // 单元格ID
 				5,        // 最大速度
-				1.0,      // 容量
 			)
 		}
 
@@ -105,6 +121,17 @@ func CreateCycleGraph(cellNum int, trafficLightInterval int, initInterval int) (
 	// 连接最后一个节点到第一个节点，形成环形
 	g.SetEdge(simple.Edge{F: nodes[int64(cellNum-1)], T: nodes[int64(0)]})
 
+	// 配置中指定了配时方案的红绿灯覆盖上述默认配时
+	if err := applySignalTimings(lights); err != nil {
+		panic(err.Error())
+	}
+
+	// 将单元格链划分为路段并注册
+	registerLinks(g)
+
+	// 识别合流单元格并注册
+	registerJunctions(g)
+
 	return g, nodes, lights
 }
 
@@ -139,34 +166,35 @@ func CreateStarRingGraph(ringCellsPerDirection int, starCellsPerDirection int, i
 	totalNodes := 5 + 8*ringCellsPerDirection + 8*starCellsPerDirection
 	nodes := make(map[int64]graph.Node, totalNodes)
 	lights := make(map[int64]*element.TrafficLightCell)
+	resetIntersections()
 
 	// 节点ID计数器
 	nextID := int64(0)
 
 	// 创建5个关键节点: A,B,C,D围成一个环，E作为中心
 	// 创建A、B、C、D、E基础节点
-	nodeA := element.NewCommonCell(nextID, 5, 1.0)
+	nodeA := newRoadCell(nextID, 5)
 	g.AddNode(nodeA)
 	nodes[nextID] = nodeA
 	nextID++
 
-	nodeB := element.NewCommonCell(nextID, 5, 1.0)
+	nodeB := newRoadCell(nextID, 5)
 	g.AddNode(nodeB)
 	nodes[nextID] = nodeB
 	nextID++
 
-	nodeC := element.NewCommonCell(nextID, 5, 1.0)
+	nodeC := newRoadCell(nextID, 5)
 	g.AddNode(nodeC)
 	nodes[nextID] = nodeC
 	nextID++
 
-	nodeD := element.NewCommonCell(nextID, 5, 1.0)
+	nodeD := newRoadCell(nextID, 5)
 	g.AddNode(nodeD)
 	nodes[nextID] = nodeD
 	nextID++
 
 	// 创建E节点（中心节点）
-	nodeE := element.NewCommonCell(nextID, 5, 1.0)
+	nodeE := newRoadCell(nextID, 5)
 	g.AddNode(nodeE)
 	nodes[nextID] = nodeE
 	nextID++
@@ -203,6 +231,9 @@ func CreateStarRingGraph(ringCellsPerDirection int, starCellsPerDirection int, i
 		{nodeE, nodeD, starCellsPerDirection, false, -1}, // E -> D
 	}
 
+	// 通向中心的四个进口道红绿灯，按相位顺序排列
+	approachLights := make([]*element.TrafficLightCell, 4)
+
 	// 为每个连接创建元胞链
 	for connectionIndex, conn := range connections {
 		// 每个方向的中间元胞数量
@@ -229,26 +260,20 @@ func CreateStarRingGraph(ringCellsPerDirection int, starCellsPerDirection int, i
 				phaseInterval := [2]int{phaseStart, phaseEnd}
 
 				// 创建红绿灯节点
-				light := element.NewTrafficLightCell(
-					id,            // 节点ID
-					5,             // 最大速度
-					1.0,           // 容量
+				light := newRoadCell(
+					id, // 节点ID
+					5,  // 最大速度
+				).ChangeToTrafficLightCell(
 					initInterval,  // 周期长度
 					phaseInterval, // 相位区间
 				)
 
-				// 随机设置初始计数，使红绿灯初始相位随机分布
-				randomCount := 1
-				if initInterval > 1 {
-					randomCount = rand.IntN(initInterval-1) + 1
-				}
-				light.SetCount(randomCount)
-
 				cell = light
 				lights[id] = light
+				approachLights[conn.lightIndex] = light
 			} else {
 				// 创建普通节点
-				cell = element.NewCommonCell(id, 5, 1.0)
+				cell = newRoadCell(id, 5)
 			}
 
 			g.AddNode(cell)
@@ -278,9 +303,53 @@ func CreateStarRingGraph(ringCellsPerDirection int, starCellsPerDirection int, i
 		_ = connectionIndex
 	}
 
+	// 四个进口道组成中心交叉口，每个相位占四分之一周期（含黄灯和全红）
+	registerIntersection(newStarRingIntersection(nodeE.ID(), approachLights, initInterval))
+
+	// 配置中指定了配时方案的交叉口覆盖上述默认配时
+	if err := applySignalTimings(lights); err != nil {
+		panic(err.Error())
+	}
+
+	// 将单元格链划分为路段并注册
+	registerLinks(g)
+
+	// 识别合流单元格并注册
+	registerJunctions(g)
+
 	return g, nodes, lights
 }
 
+// newStarRingIntersection 创建星形环形路网的中心交叉口
+// 四个进口道依次放行，每个相位的绿灯时长为四分之一周期减去黄灯和全红时长，
+// 最后一个相位补足周期余数，初始计数随机
+func newStarRingIntersection(id int64, approachLights []*element.TrafficLightCell, initInterval int) *element.Intersection {
+	amber, allRed := signalClearance()
+
+	phases := make([]element.SignalPhase, len(approachLights))
+	for i, light := range approachLights {
+		slot := initInterval / len(approachLights)
+		if i == len(approachLights)-1 {
+			slot = initInterval - slot*(len(approachLights)-1)
+		}
+		phases[i] = element.SignalPhase{
+			Lights: []*element.TrafficLightCell{light},
+			Green:  max(slot-amber-allRed, 1),
+		}
+	}
+
+	in := element.NewIntersection(id, phases, amber, allRed)
+
+	// 随机设置初始计数，使交叉口初始相位随机分布
+	randomCount := 1
+	if in.GetInterval() > 1 {
+		randomCount = rand.IntN(in.GetInterval()-1) + 1
+	}
+	in.SetCount(randomCount)
+
+	return in
+}
+
 // VerifyStarRingGraphConnectivity 验证星形环形图的强连通性
 // 参数:
 //   - g: 要验证的图
@@ -407,12 +476,14 @@ func GetGraphEdgesAndNodes(g *simple.DirectedGraph, nodes map[int64]graph.Node,
 		if cell, ok := node.(element.Cell); ok {
 			nodeInfo["maxSpeed"] = cell.MaxSpeed()
 			nodeInfo["capacity"] = cell.Capacity()
+			nodeInfo["lanes"] = cell.Lanes()
 
 			// 判断是否是红绿灯节点
 			if light, isLight := lights[id]; isLight {
 				nodeInfo["type"] = "trafficLight"
 				nodeInfo["interval"] = light.GetInterval()
 				nodeInfo["phaseInterval"] = light.GetTruePhaseInterval()
+				nodeInfo["count"] = light.GetCount()
 			} else {
 				nodeInfo["type"] = "common"
 			}
@@ -437,6 +508,31 @@ func GetGraphEdgesAndNodes(g *simple.DirectedGraph, nodes map[int64]graph.Node,
 	}
 	result["edges"] = edgesInfo
 
+	// 保存交叉口信息
+	intersectionsInfo := make([]map[string]interface{}, 0, len(intersections))
+	for _, id := range sortedIntersectionIDs() {
+		in := intersections[id]
+		phasesInfo := make([]map[string]interface{}, 0, len(in.GetPhases()))
+		for _, phase := range in.GetPhases() {
+			lightIDs := make([]int64, 0, len(phase.Lights))
+			for _, light := range phase.Lights {
+				lightIDs = append(lightIDs, light.ID())
+			}
+			phasesInfo = append(phasesInfo, map[string]interface{}{
+				"lights": lightIDs,
+				"green":  phase.Green,
+			})
+		}
+		intersectionsInfo = append(intersectionsInfo, map[string]interface{}{
+			"id":         id,
+			"phases":     phasesInfo,
+			"amberTime":  in.GetAmberTime(),
+			"allRedTime": in.GetAllRedTime(),
+			"count":      in.GetCount(),
+		})
+	}
+	result["intersections"] = intersectionsInfo
+
 	return result, nil
 }
 
@@ -523,6 +619,7 @@ func CreateGridGraph(rows, cols, cellsPerEdge, initInterval int) (*simple.Direct
 	// 创建存储节点的映射
 	nodes := make(map[int64]graph.Node, totalNodes)
 	lights := make(map[int64]*element.TrafficLightCell) // 空映射，因为不创建红绿灯
+	resetIntersections()
 
 	// 节点ID计数器
 	nextID := int64(0)
@@ -532,11 +629,12 @@ func CreateGridGraph(rows, cols, cellsPerEdge, initInterval int) (*simple.Direct
 	for i := 0; i < rows; i++ {
 		intersections[i] = make([]graph.Node, cols)
 		for j := 0; j < cols; j++ {
-			// 所有交叉口都使用普通单元格，但容量设置为2
-			node := element.NewCommonCell(
-				nextID, // 单元格ID
-				5,      // 最大速度
-				2.0,    // 容量设置为2，增加交叉口容量
+			// 所有交叉口都使用普通单元格，但每条车道容量设置为2
+			node := element.NewMultiLaneCell(
+				nextID,                   // 单元格ID
+				5,                        // 最大速度
+				2.0*float64(roadLanes()), // 每条车道容量设置为2，增加交叉口容量
+				roadLanes(),              // 车道数
 			)
 
 			g.AddNode(node)
@@ -575,6 +673,17 @@ func CreateGridGraph(rows, cols, cellsPerEdge, initInterval int) (*simple.Direct
 		}
 	}
 
+	// 网格路网没有信号节点，配置的配时方案ID均无法匹配
+	if err := applySignalTimings(lights); err != nil {
+		panic(err.Error())
+	}
+
+	// 将单元格链划分为路段并注册
+	registerLinks(g)
+
+	// 识别合流单元格并注册
+	registerJunctions(g)
+
 	return g, nodes, lights
 }
 
@@ -583,10 +692,9 @@ func createRoadCells(g *simple.DirectedGraph, nodes map[int64]graph.Node, from,
 	// 创建道路元胞
 	roadCells := make([]graph.Node, cellsPerEdge)
 	for k := 0; k < cellsPerEdge; k++ {
-		cell := element.NewCommonCell(
+		cell := newRoadCell(
 			*nextID, // 单元格ID
-			5,       // 最大速度
-			1.0,     // 容量为1，标准道路容量
+			5,       // 最大速度，每条车道容量为1
 		)
 		g.AddNode(cell)
 		nodes[*nextID] = cell
@@ -0,0 +1,202 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"simAndLearning/element"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// graphFileNode 对应SaveGraphToJSON写出的节点信息
+type graphFileNode struct {
+	ID            int64   `json:"id"`
+	Type          string  `json:"type"`
+	MaxSpeed      int     `json:"maxSpeed"`
+	Capacity      float64 `json:"capacity"`
+	Lanes         int     `json:"lanes"`
+	Interval      int     `json:"interval"`
+	PhaseInterval [2]int  `json:"phaseInterval"`
+	Count         int     `json:"count"`
+}
+
+// graphFileEdge 对应SaveGraphToJSON写出的边信息
+type graphFileEdge struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// graphFilePhase 对应SaveGraphToJSON写出的交叉口相位信息
+type graphFilePhase struct {
+	Lights []int64 `json:"lights"`
+	Green  int     `json:"green"`
+}
+
+// graphFileIntersection 对应SaveGraphToJSON写出的交叉口信息
+type graphFileIntersection struct {
+	ID         int64            `json:"id"`
+	Phases     []graphFilePhase `json:"phases"`
+	AmberTime  int              `json:"amberTime"`
+	AllRedTime int              `json:"allRedTime"`
+	Count      int              `json:"count"`
+}
+
+// graphFile 对应SaveGraphToJSON写出的完整路网文件
+type graphFile struct {
+	Nodes         []graphFileNode         `json:"nodes"`
+	Edges         []graphFileEdge         `json:"edges"`
+	Intersections []graphFileIntersection `json:"intersections"`
+}
+
+// LoadGraphFromJSON 从SaveGraphToJSON保存的JSON文件中重建路网图
+// 红绿灯单元格会恢复周期、绿灯相位区间和保存时的计数，交叉口会恢复相位方案和计数，
+// 保证重复实验使用完全相同的路网
+//
+// 参数:
+//   - filePath: 路网文件路径(*_Graph.json)
+//
+// 返回:
+//   - *simple.DirectedGraph: 重建的有向图
+//   - map[int64]graph.Node: 图中所有节点的映射
+//   - map[int64]*element.TrafficLightCell: 红绿灯节点的映射
+//   - error: 如果读取或解析文件失败，返回错误
+func LoadGraphFromJSON(filePath string) (*simple.DirectedGraph, map[int64]graph.Node, map[int64]*element.TrafficLightCell, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("读取路网文件失败: %v", err)
+	}
+
+	var file graphFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, nil, fmt.Errorf("解析路网文件失败: %v", err)
+	}
+	if len(file.Nodes) == 0 {
+		return nil, nil, nil, fmt.Errorf("路网文件中没有节点: %s", filePath)
+	}
+
+	g := simple.NewDirectedGraph()
+	nodes := make(map[int64]graph.Node, len(file.Nodes))
+	lights := make(map[int64]*element.TrafficLightCell)
+	resetIntersections()
+
+	// 重建节点
+	for _, info := range file.Nodes {
+		if _, exists := nodes[info.ID]; exists {
+			return nil, nil, nil, fmt.Errorf("节点 %d 重复", info.ID)
+		}
+
+		// 旧版本文件没有保存车道数，按单车道处理
+		lanes := max(info.Lanes, 1)
+
+		var node graph.Node
+		switch info.Type {
+		case "trafficLight":
+			light := element.NewMultiLaneCell(info.ID, info.MaxSpeed, info.Capacity, lanes).ChangeToTrafficLightCell(info.Interval, info.PhaseInterval)
+			// 旧版本文件没有保存计数，此时保持初始计数
+			if info.Count > 0 && info.Count <= info.Interval {
+				light.SetCount(info.Count)
+			}
+			node = light
+			lights[info.ID] = light
+		case "common":
+			node = element.NewMultiLaneCell(info.ID, info.MaxSpeed, info.Capacity, lanes)
+		default:
+			return nil, nil, nil, fmt.Errorf("节点 %d 类型未知: %s", info.ID, info.Type)
+		}
+
+		g.AddNode(node)
+		nodes[info.ID] = node
+	}
+
+	// 重建边
+	for _, edge := range file.Edges {
+		from, ok := nodes[edge.From]
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("边的起点 %d 不存在", edge.From)
+		}
+		to, ok := nodes[edge.To]
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("边的终点 %d 不存在", edge.To)
+		}
+		g.SetEdge(simple.Edge{F: from, T: to})
+	}
+
+	// 重建交叉口，旧版本文件没有交叉口信息，红绿灯保持独立运行
+	for _, info := range file.Intersections {
+		in, err := buildFileIntersection(info, lights)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		registerIntersection(in)
+	}
+
+	// 配置中指定了配时方案的信号节点覆盖文件中保存的配时
+	if err := applySignalTimings(lights); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 将单元格链划分为路段并注册
+	registerLinks(g)
+
+	// 识别合流单元格并注册
+	registerJunctions(g)
+
+	return g, nodes, lights, nil
+}
+
+// buildFileIntersection 根据文件中的交叉口信息重建交叉口，并恢复保存时的计数
+func buildFileIntersection(info graphFileIntersection, lights map[int64]*element.TrafficLightCell) (*element.Intersection, error) {
+	if len(info.Phases) == 0 {
+		return nil, fmt.Errorf("交叉口 %d 没有相位", info.ID)
+	}
+
+	phases := make([]element.SignalPhase, 0, len(info.Phases))
+	for _, phaseInfo := range info.Phases {
+		if phaseInfo.Green <= 0 || len(phaseInfo.Lights) == 0 {
+			return nil, fmt.Errorf("交叉口 %d 的相位无效", info.ID)
+		}
+		phase := element.SignalPhase{Green: phaseInfo.Green}
+		for _, lightID := range phaseInfo.Lights {
+			light, ok := lights[lightID]
+			if !ok {
+				return nil, fmt.Errorf("交叉口 %d 的红绿灯 %d 不存在", info.ID, lightID)
+			}
+			phase.Lights = append(phase.Lights, light)
+		}
+		phases = append(phases, phase)
+	}
+	if info.AmberTime < 0 || info.AllRedTime < 0 {
+		return nil, fmt.Errorf("交叉口 %d 的清空时间无效", info.ID)
+	}
+
+	in := element.NewIntersection(info.ID, phases, info.AmberTime, info.AllRedTime)
+	if info.Count > 0 && info.Count <= in.GetInterval() {
+		in.SetCount(info.Count)
+	}
+
+	return in, nil
+}
+
+// SaveFileGraph 从已保存的路网文件重建路网，并将其复制保存到本次运行的路网文件
+//
+// 参数:
+//   - sourcePath: 已保存的路网文件路径
+//   - filePath: 本次运行的保存路径
+//
+// 返回:
+//   - *simple.DirectedGraph: 重建的有向图
+//   - map[int64]graph.Node: 图中所有节点的映射
+//   - map[int64]*element.TrafficLightCell: 红绿灯节点的映射
+//   - error: 如果读取或保存过程中发生错误，返回错误
+func SaveFileGraph(sourcePath string, filePath string) (*simple.DirectedGraph, map[int64]graph.Node, map[int64]*element.TrafficLightCell, error) {
+	g, nodes, lights, err := LoadGraphFromJSON(sourcePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = SaveGraphToJSON(g, nodes, lights, filePath)
+
+	return g, nodes, lights, err
+}
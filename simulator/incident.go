@@ -0,0 +1,143 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"simAndLearning/log"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// Incident 表示一个在指定时间段内关闭若干单元格部分车道的事故
+type Incident struct {
+	name      string
+	cells     []element.Cell
+	start     int
+	end       int
+	reduction float64
+	active    bool
+}
+
+// incidents 保存配置的事故，由InitIncidents在路网创建后填充
+var incidents []*Incident
+
+// InitIncidents 根据配置创建事故，单元格和路段ID在当前路网中查找
+//
+// 参数:
+//   - g: 路网图
+//
+// 返回:
+//   - error: 如果配置的单元格或路段不存在，返回错误
+func InitIncidents(g *simple.DirectedGraph) error {
+	incidents = nil
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return nil
+	}
+
+	for i, incidentCfg := range cfg.Incidents {
+		incident := &Incident{
+			name:      incidentCfg.Name,
+			start:     incidentCfg.StartStep,
+			end:       incidentCfg.StartStep + incidentCfg.Duration,
+			reduction: incidentCfg.CapacityReduction,
+		}
+		if incident.name == "" {
+			incident.name = fmt.Sprintf("incident%d", i)
+		}
+
+		cells, err := resolveCells(g, incidentCfg.Cells, incidentCfg.Links)
+		if err != nil {
+			return fmt.Errorf("事故 %s: %v", incident.name, err)
+		}
+		for _, cell := range cells {
+			if incident.reduction < 1 && incident.closedLanes(cell) >= cell.Lanes() {
+				return fmt.Errorf("事故 %s: 单元格 %d 只有%d条车道，容量降低比例%.2f会关闭全部车道", incident.name, cell.ID(), cell.Lanes(), incident.reduction)
+			}
+		}
+		incident.cells = cells
+
+		incidents = append(incidents, incident)
+	}
+	return nil
+}
+
+// UpdateIncidents 在每个时间步车辆移动之前调用，开始或结束事故并更新受影响单元格关闭的车道
+// 同一单元格上同时发生的多个事故关闭的车道数相加，最多关闭全部车道
+//
+// 参数:
+//   - timeStep: 当前时间步
+func UpdateIncidents(timeStep int) {
+	changed := false
+	for _, incident := range incidents {
+		active := timeStep >= incident.start && timeStep < incident.end
+		if active == incident.active {
+			continue
+		}
+		incident.active = active
+		changed = true
+
+		if active {
+			log.WriteLog(fmt.Sprintf("Incident %s Started: Step %d, Cells %d, Capacity Reduction %.2f", incident.name, timeStep, len(incident.cells), incident.reduction))
+		} else {
+			log.WriteLog(fmt.Sprintf("Incident %s Cleared: Step %d", incident.name, timeStep))
+		}
+	}
+	if !changed {
+		return
+	}
+
+	closed := make(map[element.Cell]int)
+	for _, incident := range incidents {
+		for _, cell := range incident.cells {
+			if _, ok := closed[cell]; !ok {
+				closed[cell] = 0
+			}
+			if incident.active {
+				closed[cell] += incident.closedLanes(cell)
+			}
+		}
+	}
+	for cell, lanes := range closed {
+		cell.SetClosedLanes(min(lanes, cell.Lanes()))
+	}
+}
+
+// closedLanes 返回事故在单元格上关闭的车道数，按容量降低比例向上取整
+func (incident *Incident) closedLanes(cell element.Cell) int {
+	return int(math.Ceil(float64(cell.Lanes())*incident.reduction - 1e-9))
+}
+
+// GetActiveIncidentCount 返回当前正在发生的事故数量
+func GetActiveIncidentCount() int {
+	count := 0
+	for _, incident := range incidents {
+		if incident.active {
+			count++
+		}
+	}
+	return count
+}
+
+// resolveCells 在当前路网中查找指定ID的单元格和路段包含的所有单元格
+func resolveCells(g *simple.DirectedGraph, cellIDs, linkIDs []int64) ([]element.Cell, error) {
+	cells := make([]element.Cell, 0, len(cellIDs))
+	for _, id := range cellIDs {
+		cell, ok := g.Node(id).(element.Cell)
+		if !ok {
+			return nil, fmt.Errorf("单元格 %d 不存在", id)
+		}
+		cells = append(cells, cell)
+	}
+	for _, id := range linkIDs {
+		if id < 0 || int(id) >= len(links) {
+			return nil, fmt.Errorf("路段 %d 不存在", id)
+		}
+		for _, node := range links[id].Flat() {
+			cells = append(cells, node.(element.Cell))
+		}
+	}
+	return cells, nil
+}
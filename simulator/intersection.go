@@ -0,0 +1,44 @@
+package simulator
+
+import (
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"sort"
+)
+
+// intersections 保存当前路网中的信号交叉口，由创建或加载路网的函数填充
+var intersections map[int64]*element.Intersection = make(map[int64]*element.Intersection)
+
+// GetIntersections 返回当前路网中的所有信号交叉口
+func GetIntersections() map[int64]*element.Intersection {
+	return intersections
+}
+
+// resetIntersections 清空交叉口注册表，在创建新路网前调用
+func resetIntersections() {
+	intersections = make(map[int64]*element.Intersection)
+}
+
+// registerIntersection 将交叉口加入注册表
+func registerIntersection(in *element.Intersection) {
+	intersections[in.ID()] = in
+}
+
+// signalClearance 返回配置中的黄灯和全红时长
+func signalClearance() (int, int) {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return 2, 1
+	}
+	return cfg.TrafficLight.AmberTime, cfg.TrafficLight.AllRedTime
+}
+
+// sortedIntersectionIDs 返回按ID排序的交叉口ID，保证输出顺序稳定
+func sortedIntersectionIDs() []int64 {
+	ids := make([]int64, 0, len(intersections))
+	for id := range intersections {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
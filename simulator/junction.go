@@ -0,0 +1,83 @@
+package simulator
+
+import (
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// junctionRules 返回配置中的合流规则、分流规则和分流速度
+func junctionRules() (string, string, int) {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return element.MERGE_RULE_PRIORITY, element.DIVERGE_RULE_LEGACY, 2
+	}
+	junction := cfg.Graph.Junction
+	return junction.MergeRule, junction.DivergeRule, junction.DivergeSpeed
+}
+
+// registerJunctions 识别路网中的合流单元格并替换合流单元格注册表，在路网创建完成后调用
+// 各进口道按车道数、限速从高到低排列优先级，相同时ID较小的进口道优先
+func registerJunctions(g *simple.DirectedGraph) {
+	mergeRule, divergeRule, divergeSpeed := junctionRules()
+	element.SetDivergeRule(divergeRule, divergeSpeed)
+
+	js := make(map[int64]*element.Junction)
+	for _, node := range graph.NodesOf(g.Nodes()) {
+		cell, ok := node.(element.Cell)
+		if !ok || g.To(node.ID()).Len() < 2 {
+			continue
+		}
+
+		approaches := graph.NodesOf(g.To(node.ID()))
+		sort.Slice(approaches, func(i, j int) bool {
+			a, b := approaches[i].(element.Cell), approaches[j].(element.Cell)
+			if a.Lanes() != b.Lanes() {
+				return a.Lanes() > b.Lanes()
+			}
+			if a.MaxSpeed() != b.MaxSpeed() {
+				return a.MaxSpeed() > b.MaxSpeed()
+			}
+			return a.ID() < b.ID()
+		})
+
+		detectors := make([][]element.Cell, len(approaches))
+		for i, approach := range approaches {
+			detectors[i] = approachCells(g, approach.(element.Cell), cell.MaxSpeed()+1)
+		}
+
+		js[node.ID()] = element.NewJunction(cell, mergeRule, approaches, detectors)
+	}
+	element.SetJunctions(js)
+}
+
+// approachCells 从进口道上游单元格出发沿单一上游方向收集检测单元格
+// 最多收集depth个单元格，遇到其他合流单元格时停止
+func approachCells(g *simple.DirectedGraph, approach element.Cell, depth int) []element.Cell {
+	cells := []element.Cell{approach}
+	node := graph.Node(approach)
+	for len(cells) < depth {
+		prev := g.To(node.ID())
+		if prev.Len() != 1 {
+			break
+		}
+		prev.Next()
+		node = prev.Node()
+		cell, ok := node.(element.Cell)
+		if !ok || cell.ID() == approach.ID() {
+			break
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+// resolveJunctions 在车辆移动之前决定各合流单元格本时间步放行的进口道
+func resolveJunctions() {
+	for _, junction := range element.GetJunctions() {
+		junction.Resolve()
+	}
+}
@@ -0,0 +1,118 @@
+package simulator
+
+import (
+	"simAndLearning/element"
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+var (
+	// links 保存当前路网中的所有路段，按链路ID排列
+	links []*element.Link
+	// cellLinks 保存单元格ID到所属路段的映射，交叉口节点不属于任何路段
+	cellLinks map[int64]*element.Link = make(map[int64]*element.Link)
+)
+
+// GetLinks 返回当前路网中的所有路段
+func GetLinks() []*element.Link {
+	return links
+}
+
+// GetCellLink 返回单元格所属的路段
+func GetCellLink(id int64) (*element.Link, bool) {
+	link, ok := cellLinks[id]
+	return link, ok
+}
+
+// registerLinks 将路网划分为路段并替换路段注册表，在路网创建完成后调用
+func registerLinks(g *simple.DirectedGraph) {
+	links = buildLinks(g)
+	cellLinks = make(map[int64]*element.Link)
+	for _, link := range links {
+		for _, cell := range link.Flat() {
+			cellLinks[cell.ID()] = link
+		}
+	}
+}
+
+// buildLinks 将路网中的单元格链划分为路段
+//
+// 入度或出度不为1的单元格视为交叉口节点，不属于任何路段；
+// 其余单元格按连接顺序组成路段，路段从交叉口节点或红绿灯之后开始，
+// 到红绿灯（包含）或下一个交叉口节点之前结束。
+// 没有交叉口节点和红绿灯的环路从ID最小的单元格开始作为一个路段。
+// 路段ID按起始单元格ID从0开始依次分配，相同路网得到相同的划分
+//
+// 参数:
+//   - g: 路网图
+//
+// 返回:
+//   - []*element.Link: 按ID排列的路段
+func buildLinks(g *simple.DirectedGraph) []*element.Link {
+	isJunction := func(id int64) bool {
+		return g.To(id).Len() != 1 || g.From(id).Len() != 1
+	}
+	isLight := func(node graph.Node) bool {
+		_, ok := node.(*element.TrafficLightCell)
+		return ok
+	}
+	successor := func(id int64) graph.Node {
+		next := g.From(id)
+		next.Next()
+		return next.Node()
+	}
+
+	// 收集路段起点：前一个单元格为交叉口节点或红绿灯的普通链单元格
+	allNodes := graph.NodesOf(g.Nodes())
+	sort.Slice(allNodes, func(i, j int) bool { return allNodes[i].ID() < allNodes[j].ID() })
+
+	starts := make([]graph.Node, 0)
+	for _, node := range allNodes {
+		if isJunction(node.ID()) {
+			continue
+		}
+		prev := g.To(node.ID())
+		prev.Next()
+		if isJunction(prev.Node().ID()) || isLight(prev.Node()) {
+			starts = append(starts, node)
+		}
+	}
+
+	visited := make(map[int64]bool)
+	walk := func(start graph.Node) []graph.Node {
+		chain := make([]graph.Node, 0)
+		for node := start; !visited[node.ID()]; {
+			visited[node.ID()] = true
+			chain = append(chain, node)
+			if isLight(node) {
+				break
+			}
+			next := successor(node.ID())
+			if isJunction(next.ID()) {
+				break
+			}
+			node = next
+		}
+		return chain
+	}
+
+	chains := make([][]graph.Node, 0, len(starts))
+	for _, start := range starts {
+		chains = append(chains, walk(start))
+	}
+
+	// 剩余未访问的单元格属于没有交叉口节点和红绿灯的环路
+	for _, node := range allNodes {
+		if !visited[node.ID()] && !isJunction(node.ID()) {
+			chains = append(chains, walk(node))
+		}
+	}
+
+	result := make([]*element.Link, 0, len(chains))
+	for i, chain := range chains {
+		result = append(result, element.NewLinkFromCells(int64(i), chain))
+	}
+	return result
+}
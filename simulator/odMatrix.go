@@ -0,0 +1,177 @@
+package simulator
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"simAndLearning/config"
+	"sort"
+	"strconv"
+)
+
+// ODMatrix 表示小区到小区的出行需求矩阵
+// 计划车辆按矩阵中各起终点对的份额随机选择起终点小区，再从起点小区的进口单元格进入、在终点小区的出口单元格离开
+type ODMatrix struct {
+	pairs      [][2]int64 // 起终点小区对
+	cumulative []float64  // 各起终点对的累计出行量
+	total      float64    // 矩阵中的出行总量
+}
+
+// odMatrix 保存当前使用的出行需求矩阵，未配置时为nil
+var odMatrix *ODMatrix
+
+// LoadODMatrix 按配置读取出行需求矩阵，矩阵中的小区在交通小区注册表中查找，需要先调用LoadZones
+// 未配置矩阵文件时不使用矩阵
+//
+// 返回:
+//   - *ODMatrix: 读取的出行需求矩阵，未配置时为nil
+//   - error: 如果读取或解析文件失败，返回错误
+func LoadODMatrix() (*ODMatrix, error) {
+	odMatrix = nil
+	cfg := config.GetConfig()
+	if cfg == nil || cfg.Demand.ODMatrix.FilePath == "" {
+		return nil, nil
+	}
+
+	matrix, err := readODMatrixFile(cfg.Demand.ODMatrix.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	odMatrix = matrix
+	return matrix, nil
+}
+
+// readODMatrixFile 读取出行需求矩阵文件，出行量可以是出行次数或份额，按总和归一化
+//
+// 文件格式:
+//
+//	第一行为标题(Origin,Destination,Trips)
+//	之后每行包含起点小区ID、终点小区ID和出行量
+func readODMatrixFile(filePath string) (*ODMatrix, error) {
+	records, err := readCSVRecords(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := &ODMatrix{}
+	for i, record := range records {
+		if len(record) < 3 {
+			return nil, fmt.Errorf("%s 第%d行字段不足", filePath, i+2)
+		}
+		origin, err1 := strconv.ParseInt(record[0], 10, 64)
+		destination, err2 := strconv.ParseInt(record[1], 10, 64)
+		trips, err3 := strconv.ParseFloat(record[2], 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("%s 第%d行数据无效", filePath, i+2)
+		}
+		if trips < 0 {
+			return nil, fmt.Errorf("%s 第%d行出行量不能为负", filePath, i+2)
+		}
+		if trips == 0 {
+			continue
+		}
+		if zones[origin] == nil || len(zones[origin].entries) == 0 {
+			return nil, fmt.Errorf("%s 第%d行小区 %d 没有进口单元格", filePath, i+2, origin)
+		}
+		if zones[destination] == nil || len(zones[destination].exits) == 0 {
+			return nil, fmt.Errorf("%s 第%d行小区 %d 没有出口单元格", filePath, i+2, destination)
+		}
+
+		matrix.total += trips
+		matrix.pairs = append(matrix.pairs, [2]int64{origin, destination})
+		matrix.cumulative = append(matrix.cumulative, matrix.total)
+	}
+
+	if matrix.total <= 0 {
+		return nil, fmt.Errorf("%s 中没有出行需求", filePath)
+	}
+	return matrix, nil
+}
+
+// readCSVRecords 读取CSV文件并去掉标题行
+func readCSVRecords(filePath string) ([][]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %v", filePath, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%s 中没有数据", filePath)
+	}
+	return records[1:], nil
+}
+
+// Total 返回矩阵中的出行总量
+func (m *ODMatrix) Total() float64 {
+	return m.total
+}
+
+// samplePair 按各起终点对的份额随机选择起终点小区对
+// origin不为空时只在从该小区出发的起终点对中选择，返回false表示该小区没有出行需求
+func (m *ODMatrix) samplePair(origin *Zone) ([2]int64, bool) {
+	if origin == nil {
+		r := rand.Float64() * m.total
+		return m.pairs[min(sort.SearchFloat64s(m.cumulative, r), len(m.pairs)-1)], true
+	}
+
+	total := 0.0
+	for i, pair := range m.pairs {
+		if pair[0] == origin.id {
+			total += m.tripsOf(i)
+		}
+	}
+	if total <= 0 {
+		return [2]int64{}, false
+	}
+	r := rand.Float64() * total
+	last := [2]int64{}
+	for i, pair := range m.pairs {
+		if pair[0] != origin.id {
+			continue
+		}
+		last = pair
+		if r -= m.tripsOf(i); r < 0 {
+			return pair, true
+		}
+	}
+	return last, true
+}
+
+// tripsOf 返回第i个起终点对的出行量
+func (m *ODMatrix) tripsOf(i int) float64 {
+	if i == 0 {
+		return m.cumulative[0]
+	}
+	return m.cumulative[i] - m.cumulative[i-1]
+}
+
+// EffectiveDemandMultiplier 返回需求曲线的乘数
+// 配置使用矩阵出行总量时，乘数使一天的总需求等于矩阵中的出行总量，否则返回配置的乘数
+//
+// 参数:
+//   - multiplier: 配置的需求乘数
+//   - profile: 当天使用的原始需求曲线
+func EffectiveDemandMultiplier(multiplier float64, profile []float64) float64 {
+	cfg := config.GetConfig()
+	if odMatrix == nil || cfg == nil || !cfg.Demand.ODMatrix.UseTripTotal {
+		return multiplier
+	}
+
+	rawTotal := 0.0
+	for _, d := range profile {
+		rawTotal += d
+	}
+	if rawTotal <= 0 {
+		return multiplier
+	}
+	return odMatrix.total / rawTotal
+}
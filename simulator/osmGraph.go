@@ -0,0 +1,434 @@
+package simulator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"simAndLearning/element"
+	"strconv"
+	"strings"
+
+	"math/rand/v2"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// osmTag 表示OSM元素上的一个标签
+type osmTag struct {
+	K string `xml:"k,attr"`
+	V string `xml:"v,attr"`
+}
+
+// osmNode 表示OSM中的一个节点
+type osmNode struct {
+	ID   int64    `xml:"id,attr"`
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Tags []osmTag `xml:"tag"`
+}
+
+// osmNodeRef 表示道路中引用的节点
+type osmNodeRef struct {
+	Ref int64 `xml:"ref,attr"`
+}
+
+// osmWay 表示OSM中的一条道路
+type osmWay struct {
+	ID   int64        `xml:"id,attr"`
+	Refs []osmNodeRef `xml:"nd"`
+	Tags []osmTag     `xml:"tag"`
+}
+
+// osmFile 表示OSM XML文件的内容
+type osmFile struct {
+	Nodes []osmNode `xml:"node"`
+	Ways  []osmWay  `xml:"way"`
+}
+
+// osmRoadDefaults 各类可通行道路的默认限速(km/h)和单向车道数
+var osmRoadDefaults = map[string]struct {
+	speed float64
+	lanes int
+}{
+	"motorway":       {100, 2},
+	"motorway_link":  {60, 1},
+	"trunk":          {80, 2},
+	"trunk_link":     {50, 1},
+	"primary":        {60, 1},
+	"primary_link":   {40, 1},
+	"secondary":      {50, 1},
+	"secondary_link": {40, 1},
+	"tertiary":       {40, 1},
+	"tertiary_link":  {30, 1},
+	"unclassified":   {30, 1},
+	"residential":    {30, 1},
+	"living_street":  {10, 1},
+	"service":        {20, 1},
+}
+
+// osmTags 将标签列表转换为映射
+func osmTags(tags []osmTag) map[string]string {
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[tag.K] = tag.V
+	}
+	return result
+}
+
+// CreateOSMGraph 从本地OSM XML文件创建路网图
+// 可通行道路按交叉口拆分为路段，每个路段转换为一串中间单元格；
+// oneway决定生成的方向，maxspeed和lanes分别映射为单元格的最大速度和车道数（每条车道容量为1），
+// 带有highway=traffic_signals标签的节点转换为红绿灯单元格。
+// 只保留最大的强连通分量，保证任意起终点之间都存在路径
+//
+// 参数:
+//   - filePath: OSM XML文件路径(*.osm)
+//   - initInterval: 红绿灯初始周期时长
+//
+// 返回:
+//   - *simple.DirectedGraph: 创建的有向图
+//   - map[int64]graph.Node: 图中所有节点的映射
+//   - map[int64]*element.TrafficLightCell: 红绿灯节点的映射
+//   - error: 如果读取或解析文件失败，返回错误
+func CreateOSMGraph(filePath string, initInterval int) (*simple.DirectedGraph, map[int64]graph.Node, map[int64]*element.TrafficLightCell, error) {
+	if initInterval <= 0 {
+		panic("initInterval must be positive")
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("打开OSM文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var osm osmFile
+	if err := xml.NewDecoder(file).Decode(&osm); err != nil {
+		return nil, nil, nil, fmt.Errorf("解析OSM文件失败: %v", err)
+	}
+
+	osmNodes := make(map[int64]osmNode, len(osm.Nodes))
+	for _, node := range osm.Nodes {
+		osmNodes[node.ID] = node
+	}
+
+	// 筛选可通行道路，并去掉文件中不存在的节点引用（裁剪区域边界处）
+	ways := make([]osmWay, 0)
+	refCount := make(map[int64]int)
+	for _, way := range osm.Ways {
+		if _, ok := osmRoadDefaults[osmTags(way.Tags)["highway"]]; !ok {
+			continue
+		}
+		refs := make([]osmNodeRef, 0, len(way.Refs))
+		for _, ref := range way.Refs {
+			if _, ok := osmNodes[ref.Ref]; ok {
+				refs = append(refs, ref)
+			}
+		}
+		if len(refs) < 2 {
+			continue
+		}
+		way.Refs = refs
+		ways = append(ways, way)
+
+		for i, ref := range refs {
+			refCount[ref.Ref]++
+			// 道路端点视为被引用两次，保证其成为交叉口
+			if i == 0 || i == len(refs)-1 {
+				refCount[ref.Ref]++
+			}
+		}
+	}
+	if len(ways) == 0 {
+		return nil, nil, nil, fmt.Errorf("OSM文件中没有可通行道路: %s", filePath)
+	}
+
+	// 交叉口节点：道路端点、多条道路共享的节点以及信号灯节点
+	isJunction := func(id int64) bool {
+		return refCount[id] > 1 || osmTags(osmNodes[id].Tags)["highway"] == "traffic_signals"
+	}
+
+	g := simple.NewDirectedGraph()
+	nodes := make(map[int64]graph.Node)
+	lights := make(map[int64]*element.TrafficLightCell)
+	resetIntersections()
+	nextID := int64(0)
+
+	// 第一遍：拆分路段并统计每个交叉口相邻路段的最大速度和车道数
+	type osmSegment struct {
+		from, to     int64
+		meters       float64
+		speed        int
+		forwardLanes int
+		reverseLanes int // 0表示不生成反向路段
+	}
+	segments := make([]osmSegment, 0)
+	junctionSpeed := make(map[int64]int)
+	junctionLanes := make(map[int64]int)
+
+	for _, way := range ways {
+		tags := osmTags(way.Tags)
+		speed := speedToCellsPerStep(osmMaxSpeed(tags) / 3.6)
+		forwardLanes, reverseLanes := osmDirectionLanes(tags)
+
+		// addSegment 添加道路第first到第last个节点之间的路段
+		addSegment := func(first, last int) {
+			meters := 0.0
+			for j := first + 1; j <= last; j++ {
+				meters += haversineMeters(osmNodes[way.Refs[j-1].Ref], osmNodes[way.Refs[j].Ref])
+			}
+			segment := osmSegment{
+				from:         way.Refs[first].Ref,
+				to:           way.Refs[last].Ref,
+				meters:       meters,
+				speed:        speed,
+				forwardLanes: forwardLanes,
+				reverseLanes: reverseLanes,
+			}
+			segments = append(segments, segment)
+			for _, id := range []int64{segment.from, segment.to} {
+				junctionSpeed[id] = max(junctionSpeed[id], speed)
+				junctionLanes[id] = max(junctionLanes[id], forwardLanes, reverseLanes)
+			}
+		}
+
+		start := 0
+		for i := 1; i < len(way.Refs); i++ {
+			if !isJunction(way.Refs[i].Ref) && i != len(way.Refs)-1 {
+				continue
+			}
+
+			if way.Refs[start].Ref == way.Refs[i].Ref && i-start > 1 {
+				// 封闭道路(如环岛)中没有其他交叉口时，在中间节点处拆分，保留闭合路段
+				mid := (start + i) / 2
+				addSegment(start, mid)
+				addSegment(mid, i)
+			} else {
+				addSegment(start, i)
+			}
+			start = i
+		}
+	}
+
+	// 按路段顺序创建交叉口单元格，保证同一文件生成的单元格ID一致
+	junctionCells := make(map[int64]graph.Node, len(junctionSpeed))
+	createJunction := func(osmID int64) {
+		if _, exists := junctionCells[osmID]; exists {
+			return
+		}
+
+		var cell graph.Node
+		speed := junctionSpeed[osmID]
+		lanes := junctionLanes[osmID]
+		if osmTags(osmNodes[osmID].Tags)["highway"] == "traffic_signals" {
+			light := element.NewMultiLaneCell(nextID, speed, float64(lanes), lanes).ChangeToTrafficLightCell(initInterval, [2]int{0, max(initInterval/2, 1)})
+
+			// 随机设置初始计数，使红绿灯初始相位随机分布
+			randomCount := 1
+			if initInterval > 1 {
+				randomCount = rand.IntN(initInterval-1) + 1
+			}
+			light.SetCount(randomCount)
+
+			cell = light
+			lights[nextID] = light
+		} else {
+			cell = element.NewMultiLaneCell(nextID, speed, float64(lanes), lanes)
+		}
+
+		g.AddNode(cell)
+		nodes[nextID] = cell
+		junctionCells[osmID] = cell
+		nextID++
+	}
+	for _, segment := range segments {
+		createJunction(segment.from)
+		createJunction(segment.to)
+	}
+
+	// 第二遍：为每个路段的每个通行方向创建单元格链
+	createChain := func(from, to graph.Node, cellCount, speed, lanes int) {
+		prev := from
+		for j := 0; j < max(cellCount-1, 1); j++ {
+			cell := element.NewMultiLaneCell(nextID, speed, float64(lanes), lanes)
+			g.AddNode(cell)
+			nodes[nextID] = cell
+			g.SetEdge(simple.Edge{F: prev, T: cell})
+			prev = cell
+			nextID++
+		}
+		g.SetEdge(simple.Edge{F: prev, T: to})
+	}
+
+	for _, segment := range segments {
+		if segment.from == segment.to {
+			continue // 忽略重复节点形成的零长度路段
+		}
+		cellCount := lengthToCells(segment.meters)
+		from, to := junctionCells[segment.from], junctionCells[segment.to]
+		if segment.forwardLanes > 0 {
+			createChain(from, to, cellCount, segment.speed, segment.forwardLanes)
+		}
+		if segment.reverseLanes > 0 {
+			createChain(to, from, cellCount, segment.speed, segment.reverseLanes)
+		}
+	}
+
+	keepLargestStronglyConnected(g, nodes, lights)
+
+	// 配置中指定了配时方案的信号节点覆盖默认配时
+	if err := applySignalTimings(lights); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 将单元格链划分为路段并注册
+	registerLinks(g)
+
+	// 识别合流单元格并注册
+	registerJunctions(g)
+
+	return g, nodes, lights, nil
+}
+
+// osmMaxSpeed 解析maxspeed标签(km/h)，无法解析时使用道路类型的默认值
+// 支持"50"、"50 km/h"、"30 mph"以及以分号分隔的多个值（取第一个）
+func osmMaxSpeed(tags map[string]string) float64 {
+	defaultSpeed := osmRoadDefaults[tags["highway"]].speed
+
+	value := strings.TrimSpace(strings.Split(tags["maxspeed"], ";")[0])
+	if value == "" {
+		return defaultSpeed
+	}
+
+	factor := 1.0
+	if strings.HasSuffix(value, "mph") {
+		factor = MILE_TO_KM
+		value = strings.TrimSpace(strings.TrimSuffix(value, "mph"))
+	} else {
+		value = strings.TrimSpace(strings.TrimSuffix(value, "km/h"))
+	}
+
+	speed, err := strconv.ParseFloat(value, 64)
+	if err != nil || speed <= 0 {
+		return defaultSpeed
+	}
+	return speed * factor
+}
+
+// osmDirectionLanes 根据oneway和lanes标签计算正向和反向的车道数
+// 反向车道数为0表示单向道路
+func osmDirectionLanes(tags map[string]string) (int, int) {
+	parseLanes := func(key string) int {
+		lanes, err := strconv.Atoi(strings.TrimSpace(strings.Split(tags[key], ";")[0]))
+		if err != nil || lanes <= 0 {
+			return 0
+		}
+		return lanes
+	}
+
+	defaultLanes := osmRoadDefaults[tags["highway"]].lanes
+	totalLanes := parseLanes("lanes")
+
+	// 判断通行方向
+	forward, reverse := true, true
+	switch tags["oneway"] {
+	case "yes", "true", "1":
+		reverse = false
+	case "-1", "reverse":
+		forward = false
+	case "no", "false", "0":
+	default:
+		if tags["highway"] == "motorway" || tags["junction"] == "roundabout" || tags["junction"] == "circular" {
+			reverse = false
+		}
+	}
+
+	// 单向道路的车道全部用于一个方向
+	if !reverse || !forward {
+		lanes := totalLanes
+		if lanes == 0 {
+			lanes = defaultLanes
+		}
+		if !reverse {
+			return lanes, 0
+		}
+		return 0, lanes
+	}
+
+	// 双向道路优先使用分方向车道数，否则平分总车道数
+	forwardLanes := parseLanes("lanes:forward")
+	reverseLanes := parseLanes("lanes:backward")
+	if forwardLanes == 0 {
+		forwardLanes = defaultLanes
+		if totalLanes > 0 {
+			forwardLanes = max((totalLanes+1)/2, 1)
+		}
+	}
+	if reverseLanes == 0 {
+		reverseLanes = defaultLanes
+		if totalLanes > 0 {
+			reverseLanes = max(totalLanes/2, 1)
+		}
+	}
+	return forwardLanes, reverseLanes
+}
+
+// haversineMeters 计算两个OSM节点之间的球面距离(米)
+func haversineMeters(a, b osmNode) float64 {
+	const earthRadius = 6371000.0
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// keepLargestStronglyConnected 只保留图中最大的强连通分量，移除其余节点
+func keepLargestStronglyConnected(g *simple.DirectedGraph, nodes map[int64]graph.Node, lights map[int64]*element.TrafficLightCell) {
+	components := topo.TarjanSCC(g)
+	largest := 0
+	for i, component := range components {
+		if len(component) > len(components[largest]) {
+			largest = i
+		}
+	}
+
+	keep := make(map[int64]struct{}, len(components[largest]))
+	for _, node := range components[largest] {
+		keep[node.ID()] = struct{}{}
+	}
+
+	for id := range nodes {
+		if _, ok := keep[id]; !ok {
+			g.RemoveNode(id)
+			delete(nodes, id)
+			delete(lights, id)
+		}
+	}
+}
+
+// SaveOSMGraph 从OSM文件创建路网并保存到JSON文件
+//
+// 参数:
+//   - osmPath: OSM XML文件路径
+//   - initInterval: 红绿灯初始周期时长
+//   - filePath: 保存路径
+//
+// 返回:
+//   - *simple.DirectedGraph: 创建的有向图
+//   - map[int64]graph.Node: 图中所有节点的映射
+//   - map[int64]*element.TrafficLightCell: 红绿灯节点的映射
+//   - error: 如果创建或保存过程中发生错误，返回错误
+func SaveOSMGraph(osmPath string, initInterval int, filePath string) (*simple.DirectedGraph, map[int64]graph.Node, map[int64]*element.TrafficLightCell, error) {
+	g, nodes, lights, err := CreateOSMGraph(osmPath, initInterval)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = SaveGraphToJSON(g, nodes, lights, filePath)
+
+	return g, nodes, lights, err
+}
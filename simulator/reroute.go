@@ -0,0 +1,115 @@
+package simulator
+
+import (
+	"math/rand/v2"
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"simAndLearning/utils"
+	"sync"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// rerouteConfig 返回动态重新规划路径的配置，未加载配置时不重新规划
+func rerouteConfig() config.RerouteConfig {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return config.RerouteConfig{}
+	}
+	return cfg.Path.Reroute
+}
+
+// sampleRerouting 按配置的份额随机决定新车辆是否可以在途中重新规划路径
+func sampleRerouting() bool {
+	share := rerouteConfig().Share
+	return share > 0 && rand.Float64() < share
+}
+
+// rerouteVehicles 为满足条件的活动车辆从当前位置重新规划路径
+// 车辆距上次规划超过定期间隔，或前方拥堵程度达到阈值时重新规划，两次规划之间至少间隔cooldown个时间步；
+// 重新规划总是按当前的单元格通行时间选择最快路径，与出发时的路径选择方法无关，从而避开触发规划的拥堵；
+// 在车辆移动之前调用，新路径与剩余路径不同时替换剩余路径
+func rerouteVehicles(numWorkers, simTime int, g *simple.DirectedGraph) {
+	cfg := rerouteConfig()
+	if cfg.Share <= 0 || (cfg.Interval <= 0 && cfg.CongestionThreshold <= 0) || len(activeVehicles) == 0 {
+		return
+	}
+
+	activeVehiclesMutex.RLock()
+	vehiclesToProcess := make([]*element.Vehicle, 0, len(activeVehicles))
+	for vehicle := range activeVehicles {
+		if vehicle.Rerouting() {
+			vehiclesToProcess = append(vehiclesToProcess, vehicle)
+		}
+	}
+	activeVehiclesMutex.RUnlock()
+
+	var wg sync.WaitGroup
+	wg.Add(len(vehiclesToProcess))
+	vehicleChan := make(chan *element.Vehicle, numWorkers)
+
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			for vehicle := range vehicleChan {
+				if rerouteDue(vehicle, simTime, cfg) {
+					rerouteVehicle(vehicle, simTime, g)
+				}
+				wg.Done()
+			}
+		}()
+	}
+
+	for _, vehicle := range vehiclesToProcess {
+		vehicleChan <- vehicle
+	}
+	close(vehicleChan)
+	wg.Wait()
+}
+
+// rerouteDue 判断车辆本时间步是否需要重新规划路径
+func rerouteDue(vehicle *element.Vehicle, simTime int, cfg config.RerouteConfig) bool {
+	since := simTime - vehicle.LastRerouteTime()
+	if since < cfg.Cooldown {
+		return false
+	}
+	if cfg.Interval > 0 && since >= cfg.Interval {
+		return true
+	}
+	return cfg.CongestionThreshold > 0 && vehicle.CongestionAhead(cfg.Lookahead) >= cfg.CongestionThreshold
+}
+
+// rerouteVehicle 按当前的单元格通行时间从车辆当前位置到终点重新规划路径，路径未改变时不替换
+func rerouteVehicle(vehicle *element.Vehicle, simTime int, g *simple.DirectedGraph) {
+	pos := vehicle.CurrentPosition()
+	destination := vehicle.Destination()
+	if pos == nil || pos.ID() == destination.ID() {
+		return
+	}
+
+	path, _, err := utils.TravelTimePath(g, pos, destination)
+	if err != nil || samePath(path, vehicle.Path(), pos) {
+		return
+	}
+	vehicle.Reroute(path, simTime)
+}
+
+// samePath 判断新路径是否与原路径从当前位置开始的部分相同
+func samePath(path, oldPath []graph.Node, pos graph.Node) bool {
+	start := -1
+	for i, node := range oldPath {
+		if node.ID() == pos.ID() {
+			start = i
+			break
+		}
+	}
+	if start < 0 || len(oldPath)-start != len(path) {
+		return false
+	}
+	for i, node := range path {
+		if oldPath[start+i].ID() != node.ID() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,237 @@
+package simulator
+
+import (
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"simAndLearning/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// 路径学习的记忆范围
+const (
+	ROUTE_LEARNING_NONE   = "none"   // 不学习
+	ROUTE_LEARNING_OD     = "od"     // 同一起终点的所有驾驶员共享记忆
+	ROUTE_LEARNING_DRIVER = "driver" // 每个驾驶员(车辆ID)在每个起终点上有各自的记忆
+)
+
+// routeMemoryKey 表示一条路径记忆所属的驾驶员和起终点，按起终点共享记忆时driver为0
+type routeMemoryKey struct {
+	driver      int64
+	origin      int64
+	destination int64
+}
+
+// routeExperience 保存一条路径的行程时间记忆
+type routeExperience struct {
+	path       []graph.Node
+	travelTime float64 // 平滑后的行程时间(时间步)
+	sum        float64 // 当天经历的行程时间之和
+	count      int     // 当天经历的行程次数
+}
+
+// RouteLearningStats 保存一天的路径学习统计结果
+type RouteLearningStats struct {
+	Trips       int     // 当天完成并计入学习的行程数
+	RelativeGap float64 // 相对间隙: Σ(行程时间-该起终点当天所有驾驶员中最短的路径平均行程时间)/Σ行程时间
+	KnownRoutes int     // 记忆中的路径总数
+}
+
+var (
+	// routeMemory 保存各驾驶员或起终点上经历过的路径及其行程时间
+	routeMemory      map[routeMemoryKey]map[string]*routeExperience = make(map[routeMemoryKey]map[string]*routeExperience)
+	routeMemoryMutex sync.RWMutex
+)
+
+// routeLearningConfig 返回路径学习的配置，未加载配置时不学习
+func routeLearningConfig() (string, float64, float64) {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return ROUTE_LEARNING_NONE, 0, 0
+	}
+	return cfg.Path.Learning.Scope, cfg.Path.Learning.Smoothing, cfg.Path.Learning.Dispersion
+}
+
+// newRouteMemoryKey 按记忆范围生成路径记忆的键
+func newRouteMemoryKey(scope string, driver int64, origin, destination graph.Node) routeMemoryKey {
+	if scope != ROUTE_LEARNING_DRIVER {
+		driver = 0
+	}
+	return routeMemoryKey{driver: driver, origin: origin.ID(), destination: destination.ID()}
+}
+
+// routeMemoryPathKey 将路径转换为记忆中的路径键
+func routeMemoryPathKey(path []graph.Node) string {
+	ids := make([]string, len(path))
+	for i, node := range path {
+		ids[i] = strconv.FormatInt(node.ID(), 10)
+	}
+	return strings.Join(ids, ",")
+}
+
+// chooseLearnedPath 在记忆中的路径和路径查找器给出的候选路径之间按Logit模型选择
+// 记忆中的路径使用平滑后的行程时间，尚未经历的候选路径使用当前单元格通行时间估计的行程时间；
+// 未启用学习或没有记忆时直接返回候选路径
+//
+// 参数:
+//   - driver: 驾驶员(车辆ID)
+//   - origin: 起点
+//   - destination: 终点
+//   - candidate: 路径查找器给出的候选路径
+//
+// 返回:
+//   - []graph.Node: 选择的路径
+func chooseLearnedPath(driver int64, origin, destination graph.Node, candidate []graph.Node) []graph.Node {
+	scope, _, dispersion := routeLearningConfig()
+	if scope == ROUTE_LEARNING_NONE {
+		return candidate
+	}
+
+	routeMemoryMutex.RLock()
+	defer routeMemoryMutex.RUnlock()
+
+	known := routeMemory[newRouteMemoryKey(scope, driver, origin, destination)]
+	if len(known) == 0 {
+		return candidate
+	}
+
+	paths := make([][]graph.Node, 0, len(known)+1)
+	costs := make([]float64, 0, len(known)+1)
+	for _, key := range sortedRouteKeys(known) {
+		if experience := known[key]; experience.travelTime > 0 {
+			paths = append(paths, experience.path)
+			costs = append(costs, experience.travelTime)
+		}
+	}
+	if experience, ok := known[routeMemoryPathKey(candidate)]; !ok || experience.travelTime == 0 {
+		paths = append(paths, candidate)
+		costs = append(costs, utils.PathTravelTime(candidate))
+	}
+	if len(paths) == 0 {
+		return candidate
+	}
+
+	return paths[utils.LogitChoice(costs, dispersion)]
+}
+
+// recordRouteExperience 记录已完成行程的路径和路网内的行驶时间，在当天结束时计入记忆
+func recordRouteExperience(vehicle *element.Vehicle) {
+	scope, _, _ := routeLearningConfig()
+	if scope == ROUTE_LEARNING_NONE {
+		return
+	}
+
+	path := vehicle.GetPath()
+	od := vehicle.GetOD()
+	if len(path) == 0 || od == nil {
+		return
+	}
+	// 与未行驶路径的估计(PathTravelTime)一致，只计路网内的行驶时间，不包括在起点缓冲区的等待
+	_, _, _, _, outTime, _, _ := vehicle.Report()
+	entryTime := vehicle.EntryTime()
+
+	routeMemoryMutex.Lock()
+	defer routeMemoryMutex.Unlock()
+
+	key := newRouteMemoryKey(scope, vehicle.Index(), od[0], od[1])
+	if routeMemory[key] == nil {
+		routeMemory[key] = make(map[string]*routeExperience)
+	}
+	pathKey := routeMemoryPathKey(path)
+	experience, ok := routeMemory[key][pathKey]
+	if !ok {
+		experience = &routeExperience{path: path}
+		routeMemory[key][pathKey] = experience
+	}
+	experience.sum += float64(outTime - entryTime)
+	experience.count++
+}
+
+// UpdateRouteLearning 在一天结束时将当天经历的行程时间按指数平滑计入记忆，并计算当天的相对间隙
+// 新经历的路径直接使用当天的平均行程时间；相对间隙按起终点汇总所有驾驶员的经历，
+// 与该起终点当天平均行程时间最短的路径比较，不受记忆范围的影响
+func UpdateRouteLearning() RouteLearningStats {
+	_, smoothing, _ := routeLearningConfig()
+
+	routeMemoryMutex.Lock()
+	defer routeMemoryMutex.Unlock()
+
+	// 各起终点上每条路径当天所有驾驶员的行程时间之和与行程次数
+	type odKey struct{ origin, destination int64 }
+	type pathTotal struct {
+		sum   float64
+		count int
+	}
+	odPaths := make(map[odKey]map[string]*pathTotal)
+	for key, known := range routeMemory {
+		od := odKey{key.origin, key.destination}
+		if odPaths[od] == nil {
+			odPaths[od] = make(map[string]*pathTotal)
+		}
+		for pathKey, experience := range known {
+			if experience.count == 0 {
+				continue
+			}
+			total := odPaths[od][pathKey]
+			if total == nil {
+				total = &pathTotal{}
+				odPaths[od][pathKey] = total
+			}
+			total.sum += experience.sum
+			total.count += experience.count
+		}
+	}
+
+	// 各起终点当天的最短平均行程时间
+	best := make(map[odKey]float64, len(odPaths))
+	for od, totals := range odPaths {
+		for _, total := range totals {
+			if mean := total.sum / float64(total.count); best[od] == 0 || mean < best[od] {
+				best[od] = mean
+			}
+		}
+	}
+
+	stats := RouteLearningStats{}
+	totalTime, totalGap := 0.0, 0.0
+	for key, known := range routeMemory {
+		od := odKey{key.origin, key.destination}
+		for _, experience := range known {
+			stats.KnownRoutes++
+			if experience.count == 0 {
+				continue
+			}
+			mean := experience.sum / float64(experience.count)
+			stats.Trips += experience.count
+			totalTime += experience.sum
+			totalGap += experience.sum - best[od]*float64(experience.count)
+
+			if experience.travelTime > 0 {
+				experience.travelTime = smoothing*mean + (1-smoothing)*experience.travelTime
+			} else {
+				experience.travelTime = mean
+			}
+			experience.sum = 0
+			experience.count = 0
+		}
+	}
+
+	if totalTime > 0 {
+		stats.RelativeGap = totalGap / totalTime
+	}
+	return stats
+}
+
+// sortedRouteKeys 返回排序后的路径键，保证相同记忆下的选择顺序稳定
+func sortedRouteKeys(known map[string]*routeExperience) []string {
+	keys := make([]string, 0, len(known))
+	for key := range known {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,144 @@
+package simulator
+
+import (
+	"fmt"
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// signalControlMode 返回指定交叉口或红绿灯的控制方式
+func signalControlMode(cfg config.SignalControlConfig, id int64) string {
+	if mode, ok := cfg.Overrides[id]; ok {
+		return mode
+	}
+	return cfg.Mode
+}
+
+// ApplySignalControl 按配置为交叉口和独立红绿灯设置控制方式
+// 交叉口直接设置自适应控制；独立红绿灯使用自适应控制时，包装为单相位交叉口，
+// 原周期中的红灯时长作为全红时长保留，包装后的交叉口加入交叉口注册表
+//
+// 参数:
+//   - g: 路网图，用于确定检测区
+//   - lights: 红绿灯节点映射
+//
+// 返回:
+//   - int: 使用自适应控制的交叉口数量
+//   - error: 如果配置了未知的控制方式，返回错误
+func ApplySignalControl(g *simple.DirectedGraph, lights map[int64]*element.TrafficLightCell) (int, error) {
+	cfg := config.GetConfig().TrafficLight.Control
+	adaptive := 0
+
+	for _, id := range sortedIntersectionIDs() {
+		in := intersections[id]
+		mode := signalControlMode(cfg, id)
+		ok, err := isAdaptiveMode(mode)
+		if err != nil {
+			return adaptive, fmt.Errorf("交叉口 %d: %v", id, err)
+		}
+		if ok {
+			in.SetControl(newSignalControl(g, in, mode, cfg))
+			adaptive++
+		}
+	}
+
+	lightIDs := make([]int64, 0, len(lights))
+	for id := range lights {
+		lightIDs = append(lightIDs, id)
+	}
+	sort.Slice(lightIDs, func(i, j int) bool { return lightIDs[i] < lightIDs[j] })
+
+	for _, id := range lightIDs {
+		light := lights[id]
+		if light.GetController() != nil {
+			continue
+		}
+		mode := signalControlMode(cfg, id)
+		ok, err := isAdaptiveMode(mode)
+		if err != nil {
+			return adaptive, fmt.Errorf("红绿灯 %d: %v", id, err)
+		}
+		if !ok {
+			continue
+		}
+
+		window := light.GetTruePhaseInterval()
+		green := window[1] - window[0]
+		in := element.NewIntersection(id, []element.SignalPhase{
+			{Lights: []*element.TrafficLightCell{light}, Green: green},
+		}, 0, light.GetInterval()-green)
+		in.SetControl(newSignalControl(g, in, mode, cfg))
+		registerIntersection(in)
+		adaptive++
+	}
+
+	return adaptive, nil
+}
+
+// isAdaptiveMode 判断控制方式是否为自适应控制
+func isAdaptiveMode(mode string) (bool, error) {
+	switch mode {
+	case element.SIGNAL_CONTROL_FIXED:
+		return false, nil
+	case element.SIGNAL_CONTROL_ACTUATED, element.SIGNAL_CONTROL_MAX_PRESSURE:
+		return true, nil
+	}
+	return false, fmt.Errorf("未知的信号控制方式: %s", mode)
+}
+
+// newSignalControl 为交叉口的每个相位建立上下游检测区并创建控制策略
+func newSignalControl(g *simple.DirectedGraph, in *element.Intersection, mode string, cfg config.SignalControlConfig) *element.SignalControl {
+	phases := in.GetPhases()
+	upstream := make([][]element.Cell, len(phases))
+	downstream := make([][]element.Cell, len(phases))
+	for i, phase := range phases {
+		for _, light := range phase.Lights {
+			upstream[i] = append(upstream[i], detectorCells(g, light, cfg.DetectorLength, true)...)
+			downstream[i] = append(downstream[i], detectorCells(g, light, cfg.DetectorLength, false)...)
+		}
+	}
+
+	return element.NewSignalControl(mode, cfg.MinGreen, cfg.MaxGreen, cfg.GapTime, upstream, downstream)
+}
+
+// detectorCells 从红绿灯(或其他单元格)出发沿上游或下游方向广度优先搜索检测单元格
+// 搜索最多depth层，遇到其他红绿灯时停止，检测区不包含出发单元格本身
+func detectorCells(g *simple.DirectedGraph, light graph.Node, depth int, upstream bool) []element.Cell {
+	cells := make([]element.Cell, 0)
+	visited := map[int64]bool{light.ID(): true}
+	frontier := []graph.Node{light}
+
+	for d := 0; d < depth && len(frontier) > 0; d++ {
+		next := make([]graph.Node, 0, len(frontier))
+		for _, node := range frontier {
+			var neighbors graph.Nodes
+			if upstream {
+				neighbors = g.To(node.ID())
+			} else {
+				neighbors = g.From(node.ID())
+			}
+			for neighbors.Next() {
+				neighbor := neighbors.Node()
+				if visited[neighbor.ID()] {
+					continue
+				}
+				visited[neighbor.ID()] = true
+
+				if _, isLight := neighbor.(*element.TrafficLightCell); isLight {
+					continue
+				}
+				if cell, ok := neighbor.(element.Cell); ok {
+					cells = append(cells, cell)
+					next = append(next, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return cells
+}
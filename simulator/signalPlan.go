@@ -0,0 +1,240 @@
+package simulator
+
+import (
+	"fmt"
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"simAndLearning/log"
+	"sort"
+)
+
+// lightTiming 表示独立红绿灯的周期、绿灯区间和相位差
+type lightTiming struct {
+	interval int
+	window   [2]int
+	offset   int
+}
+
+// intersectionTiming 表示交叉口各相位的绿灯时长和相位差
+type intersectionTiming struct {
+	greens []int
+	offset int
+}
+
+// SignalScheduler 按时段配时方案和按天的周期倍数切换信号配时
+// 新配时不会立即生效，而是在各红绿灯或交叉口完成当前周期时切换，避免相位突变导致冲突方向同时放行；
+// 切换时按仿真时间步和相位差确定新周期内的计数，使各信号始终对齐到同一时钟，保持协调控制的相位差
+// 使用自适应控制的交叉口由控制策略决定绿灯时长，不参与配时切换
+type SignalScheduler struct {
+	lights map[int64]*element.TrafficLightCell
+	plans  []config.SignalPlan
+
+	// 创建时的基础配时，各时段方案在此基础上计算
+	baseLights        map[int64]lightTiming
+	baseIntersections map[int64]intersectionTiming
+
+	// 当前生效的方案索引(-1表示基础配时)和周期倍数
+	currentPlan int
+	currentMul  float64
+
+	// 等待在周期结束时切换的配时
+	pendingLights        map[int64]lightTiming
+	pendingIntersections map[int64]intersectionTiming
+}
+
+// NewSignalScheduler 创建信号配时调度器，记录当前配时作为基础配时
+// 基础相位差取配置中的配时方案(TrafficLight.Timings)
+//
+// 参数:
+//   - lights: 红绿灯节点映射
+//
+// 返回:
+//   - *SignalScheduler: 创建的调度器
+func NewSignalScheduler(lights map[int64]*element.TrafficLightCell) *SignalScheduler {
+	plans := append([]config.SignalPlan(nil), config.GetConfig().TrafficLight.Plans...)
+	sort.SliceStable(plans, func(i, j int) bool { return plans[i].StartStep < plans[j].StartStep })
+
+	s := &SignalScheduler{
+		lights:               lights,
+		plans:                plans,
+		baseLights:           make(map[int64]lightTiming),
+		baseIntersections:    make(map[int64]intersectionTiming),
+		currentPlan:          -1,
+		currentMul:           1,
+		pendingLights:        make(map[int64]lightTiming),
+		pendingIntersections: make(map[int64]intersectionTiming),
+	}
+
+	for id, light := range lights {
+		if light.GetController() == nil {
+			s.baseLights[id] = lightTiming{light.GetInterval(), light.GetTruePhaseInterval(), baseOffset(id)}
+		}
+	}
+	for id, in := range intersections {
+		if in.GetControl() == nil {
+			s.baseIntersections[id] = intersectionTiming{phaseGreens(in), baseOffset(id)}
+		}
+	}
+
+	return s
+}
+
+// Update 在每个时间步红绿灯推进之前调用，检查是否需要切换配时，并应用已到周期末的切换
+//
+// 参数:
+//   - day: 当前天数（从1开始）
+//   - timeOfDay: 一天内的时间步
+//   - timeStep: 从仿真开始计算的时间步
+func (s *SignalScheduler) Update(day int, timeOfDay int, timeStep int) {
+	plan := s.activePlan(timeOfDay)
+	mul := dayMultiplier(day)
+	if plan != s.currentPlan || mul != s.currentMul {
+		if plan != s.currentPlan {
+			log.WriteLog(fmt.Sprintf("Signal Plan Changed: %s (Day %d, %s)", s.planName(plan), day, log.ConvertTimeStepToTime(timeOfDay)))
+		}
+		if mul != s.currentMul {
+			log.WriteLog(fmt.Sprintf("TrafficLight Interval Changed: Multiplier - %.2f", mul/s.currentMul))
+		}
+		s.currentPlan = plan
+		s.currentMul = mul
+		s.schedule()
+	}
+
+	s.applyPending(timeStep)
+}
+
+// activePlan 返回指定时间步生效的时段方案索引，没有时段方案时返回-1
+func (s *SignalScheduler) activePlan(timeOfDay int) int {
+	if len(s.plans) == 0 {
+		return -1
+	}
+	// 第一个方案开始前沿用前一天最后一个方案
+	active := len(s.plans) - 1
+	for i, plan := range s.plans {
+		if plan.StartStep <= timeOfDay {
+			active = i
+		}
+	}
+	return active
+}
+
+// planName 返回方案名称
+func (s *SignalScheduler) planName(plan int) string {
+	if plan < 0 {
+		return "base"
+	}
+	if s.plans[plan].Name != "" {
+		return s.plans[plan].Name
+	}
+	return fmt.Sprintf("plan%d", plan)
+}
+
+// dayMultiplier 计算截至指定天数累计生效的周期倍数(TrafficLight.Changes)
+func dayMultiplier(day int) float64 {
+	mul := 1.0
+	for _, change := range config.GetConfig().TrafficLight.Changes {
+		if change.Day <= day {
+			mul *= change.Multiplier
+		}
+	}
+	return mul
+}
+
+// schedule 根据当前方案和倍数计算所有红绿灯和交叉口的目标配时，等待周期结束时切换
+func (s *SignalScheduler) schedule() {
+	var timings []config.SignalTiming
+	mul := s.currentMul
+	if s.currentPlan >= 0 {
+		plan := s.plans[s.currentPlan]
+		timings = plan.Timings
+		if plan.Multiplier > 0 {
+			mul *= plan.Multiplier
+		}
+	}
+
+	for id, base := range s.baseLights {
+		target := base
+		for _, timing := range timings {
+			if containsID(timing.IDs, id) {
+				target.interval, target.window = resolveLightTiming(id, target.interval, target.window, timing)
+				target.offset = timing.Offset
+			}
+		}
+		target = scaleLightTiming(id, target, mul)
+		target.offset %= target.interval
+		s.pendingLights[id] = target
+	}
+
+	for id, base := range s.baseIntersections {
+		in := intersections[id]
+		greens, offset := base.greens, base.offset
+		for _, timing := range timings {
+			if containsID(timing.IDs, id) {
+				greens = resolveIntersectionGreens(in, greens, timing)
+				offset = timing.Offset
+			}
+		}
+		scaled := make([]int, len(greens))
+		for i, green := range greens {
+			scaled[i] = max(int(float64(green)*mul), 1)
+		}
+		s.pendingIntersections[id] = intersectionTiming{scaled, offset % (sumInts(scaled) + in.GetClearanceTime())}
+	}
+}
+
+// applyPending 对已完成当前周期的红绿灯和交叉口应用等待中的配时
+// 新周期内的计数由仿真时间步和相位差确定，与仿真开始时按相位差设置的计数保持同一时钟
+func (s *SignalScheduler) applyPending(timeStep int) {
+	for id, target := range s.pendingLights {
+		light := s.lights[id]
+		if light.GetCount() == 0 || light.GetCount() == light.GetInterval() {
+			light.SetTiming(target.interval, target.window, (timeStep+target.offset)%target.interval)
+			delete(s.pendingLights, id)
+		}
+	}
+	for id, target := range s.pendingIntersections {
+		in := intersections[id]
+		if in.GetCount() == 0 || in.GetCount() == in.GetInterval() {
+			interval := sumInts(target.greens) + in.GetClearanceTime()
+			in.SetTiming(target.greens, (timeStep+target.offset)%interval)
+			delete(s.pendingIntersections, id)
+		}
+	}
+}
+
+// baseOffset 返回配置的配时方案中为信号节点指定的相位差，按顺序后面的方案优先，未指定时为0
+func baseOffset(id int64) int {
+	offset := 0
+	for _, timing := range config.GetConfig().TrafficLight.Timings {
+		if containsID(timing.IDs, id) {
+			offset = timing.Offset
+		}
+	}
+	return offset
+}
+
+// scaleLightTiming 按倍数缩放红绿灯的周期和绿灯区间，相位差保持不变
+func scaleLightTiming(id int64, timing lightTiming, mul float64) lightTiming {
+	scaled := lightTiming{
+		offset:   timing.offset,
+		interval: int(float64(timing.interval) * mul),
+		window: [2]int{
+			int(float64(timing.window[0]) * mul),
+			int(float64(timing.window[1]) * mul),
+		},
+	}
+	if scaled.interval <= 0 || scaled.window[1] <= scaled.window[0] || scaled.window[1] > scaled.interval {
+		panic(fmt.Sprintf("traffic light %d: invalid scaled timing with multiplier %.2f", id, mul))
+	}
+	return scaled
+}
+
+// containsID 判断ID列表中是否包含指定ID
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"simAndLearning/config"
+	"simAndLearning/element"
+)
+
+// applySignalTimings 按配置中的配时方案覆盖路网生成器的默认配时
+// 方案按顺序应用，ID优先匹配交叉口，其次匹配独立红绿灯；
+// 属于交叉口的红绿灯由交叉口统一配时，不能单独指定
+// 配时方案的周期和绿灯与路网不一致时触发panic，与路网生成器的参数检查保持一致
+//
+// 参数:
+//   - lights: 红绿灯节点映射
+//
+// 返回:
+//   - error: 如果ID既不是交叉口也不是红绿灯，或者是属于交叉口的红绿灯，返回错误
+func applySignalTimings(lights map[int64]*element.TrafficLightCell) error {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return nil
+	}
+
+	for _, timing := range cfg.TrafficLight.Timings {
+		for _, id := range timing.IDs {
+			if in, ok := intersections[id]; ok {
+				applyIntersectionTiming(in, timing)
+				continue
+			}
+			light, ok := lights[id]
+			if !ok {
+				return fmt.Errorf("配时方案中的ID %d 不是交叉口或红绿灯", id)
+			}
+			if in := light.GetController(); in != nil {
+				return fmt.Errorf("红绿灯 %d 属于交叉口 %d，应为交叉口指定配时方案", id, in.ID())
+			}
+			applyLightTiming(light, timing)
+		}
+	}
+	return nil
+}
+
+// applyLightTiming 为独立红绿灯应用配时方案
+func applyLightTiming(light *element.TrafficLightCell, timing config.SignalTiming) {
+	cycle, green := resolveLightTiming(light.ID(), light.GetInterval(), light.GetTruePhaseInterval(), timing)
+	light.SetTiming(cycle, green, timing.Offset%cycle)
+}
+
+// applyIntersectionTiming 为交叉口应用配时方案
+func applyIntersectionTiming(in *element.Intersection, timing config.SignalTiming) {
+	greens := resolveIntersectionGreens(in, phaseGreens(in), timing)
+	in.SetTiming(greens, timing.Offset%(sumInts(greens)+in.GetClearanceTime()))
+}
+
+// resolveLightTiming 在原配时的基础上计算配时方案对应的周期和绿灯区间
+// 未指定绿灯区间时按新旧周期的比例缩放原绿灯区间
+func resolveLightTiming(id int64, interval int, window [2]int, timing config.SignalTiming) (int, [2]int) {
+	cycle := interval
+	if timing.Cycle > 0 {
+		cycle = timing.Cycle
+	}
+
+	green := timing.Green
+	if green == [2]int{} {
+		ratio := float64(cycle) / float64(interval)
+		green = [2]int{
+			int(math.Round(float64(window[0]) * ratio)),
+			int(math.Round(float64(window[1]) * ratio)),
+		}
+		green[1] = min(max(green[1], green[0]+1), cycle)
+	}
+	if green[1] > cycle || green[1] <= green[0] {
+		panic(fmt.Sprintf("traffic light %d: green window %v does not fit cycle %d", id, green, cycle))
+	}
+
+	return cycle, green
+}
+
+// resolveIntersectionGreens 在原各相位绿灯时长的基础上计算配时方案对应的绿灯时长
+// 未指定各相位绿灯时长时，将周期扣除清空时间后按原绿灯时长的比例分配给各相位
+func resolveIntersectionGreens(in *element.Intersection, base []int, timing config.SignalTiming) []int {
+	clearance := in.GetClearanceTime()
+
+	greens := timing.PhaseGreens
+	if len(greens) == 0 {
+		greens = append([]int(nil), base...)
+		if timing.Cycle > 0 {
+			available := timing.Cycle - clearance
+			if available < len(greens) {
+				panic(fmt.Sprintf("intersection %d: cycle %d is too short for %d phases", in.ID(), timing.Cycle, len(greens)))
+			}
+			total := sumInts(greens)
+			assigned := 0
+			for i := range greens {
+				greens[i] = max(int(math.Round(float64(greens[i])*float64(available)/float64(total))), 1)
+				assigned += greens[i]
+			}
+			// 最后一个相位补足舍入误差
+			greens[len(greens)-1] = max(greens[len(greens)-1]+available-assigned, 1)
+		}
+	}
+	if len(greens) != len(in.GetPhases()) {
+		panic(fmt.Sprintf("intersection %d: %d phase greens for %d phases", in.ID(), len(greens), len(in.GetPhases())))
+	}
+
+	cycle := sumInts(greens) + clearance
+	if timing.Cycle > 0 && timing.Cycle != cycle {
+		panic(fmt.Sprintf("intersection %d: phase greens and clearance sum to %d, not cycle %d", in.ID(), cycle, timing.Cycle))
+	}
+
+	return greens
+}
+
+// phaseGreens 返回交叉口当前各相位的绿灯时长
+func phaseGreens(in *element.Intersection) []int {
+	greens := make([]int, 0, len(in.GetPhases()))
+	for _, phase := range in.GetPhases() {
+		greens = append(greens, phase.Green)
+	}
+	return greens
+}
+
+// sumInts 计算整数切片之和
+func sumInts(values []int) int {
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
@@ -25,11 +25,34 @@ func WriteData(dataFiles map[string]string) {
 	if traceFile, ok := dataFiles["trace"]; ok {
 		recorder.WriteToTraceDataCSV(traceFile)
 	}
+	// 写入行程清单
+	if tripFile, ok := dataFiles["trips"]; ok {
+		recorder.WriteToTripListCSV(tripFile)
+	}
 
 	// 手动触发垃圾回收以减少内存占用
 	runtime.GC()
 }
 
+// RecordLinkData 采样所有路段的车辆数、平均速度和密度
+func RecordLinkData(timeStep int) {
+	recorder.RecordLinkData(timeStep, links)
+}
+
+// WriteLinkData 将缓存的路段采样数据按路段平均后写入文件
+// 处理数据写入过程中可能出现的panic
+func WriteLinkData(dataFiles map[string]string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.WriteLog(fmt.Sprintf("Panic occurred during link data write: %v", r))
+		}
+	}()
+
+	if linkFile, ok := dataFiles["link"]; ok {
+		recorder.WriteToLinkDataCSV(linkFile, links)
+	}
+}
+
 // FinishSimulation 完成模拟，写入最后的数据
 // 记录写入操作的时间消耗
 func FinishSimulation(dataFiles map[string]string) {
@@ -52,6 +75,15 @@ func FinishSimulation(dataFiles map[string]string) {
 	if traceFile, ok := dataFiles["trace"]; ok {
 		recorder.WriteToTraceDataCSV(traceFile)
 	}
+	// 写入行程清单
+	if tripFile, ok := dataFiles["trips"]; ok {
+		recorder.WriteToTripListCSV(tripFile)
+	}
+
+	// 写入路段数据
+	if linkFile, ok := dataFiles["link"]; ok {
+		recorder.WriteToLinkDataCSV(linkFile, links)
+	}
 
 	elapsedTime := time.Since(startTime)
 	log.WriteLog(fmt.Sprintf("Final data write completed in %v", elapsedTime))
@@ -0,0 +1,153 @@
+package simulator
+
+import (
+	"fmt"
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"simAndLearning/log"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// speedLimitSchedule 表示解析到单元格的时段限速
+type speedLimitSchedule struct {
+	config.SpeedLimitSchedule
+	cells  []element.Cell
+	active bool
+}
+
+// SpeedLimitController 按时段方案和拥堵规则改变单元格的限速
+// 单元格的限速取路网原限速、生效的时段限速和拥堵规则限速中的最小值，
+// 不再受控的单元格恢复原限速
+type SpeedLimitController struct {
+	g         *simple.DirectedGraph
+	nodes     []graph.Node
+	schedules []*speedLimitSchedule
+	rule      config.SpeedLimitRule
+
+	// 创建时各单元格的原限速，只记录改变过限速的单元格
+	base map[element.Cell]int
+
+	// 拥堵规则当前降低限速的单元格
+	reduced map[element.Cell]bool
+}
+
+// NewSpeedLimitController 根据配置创建可变限速控制器，时段方案的单元格和路段ID在当前路网中查找
+//
+// 参数:
+//   - g: 路网图
+//   - nodes: 路网中的所有单元格，用于检测拥堵
+//
+// 返回:
+//   - *SpeedLimitController: 创建的控制器
+//   - error: 如果配置的单元格或路段不存在，返回错误
+func NewSpeedLimitController(g *simple.DirectedGraph, nodes []graph.Node) (*SpeedLimitController, error) {
+	cfg := config.GetConfig().SpeedLimits
+	c := &SpeedLimitController{
+		g:       g,
+		nodes:   nodes,
+		rule:    cfg.Rule,
+		base:    make(map[element.Cell]int),
+		reduced: make(map[element.Cell]bool),
+	}
+
+	for i, scheduleCfg := range cfg.Schedules {
+		cells, err := resolveCells(g, scheduleCfg.Cells, scheduleCfg.Links)
+		if err != nil {
+			return nil, fmt.Errorf("限速方案 %d: %v", i, err)
+		}
+		schedule := &speedLimitSchedule{SpeedLimitSchedule: scheduleCfg, cells: cells}
+		if schedule.Name == "" {
+			schedule.Name = fmt.Sprintf("schedule%d", i)
+		}
+		c.schedules = append(c.schedules, schedule)
+	}
+
+	return c, nil
+}
+
+// Update 在每个时间步车辆移动之前调用，切换时段限速并按间隔检测拥堵，限速改变时更新受影响的单元格
+//
+// 参数:
+//   - timeStep: 当前时间步
+//   - timeOfDay: 一天内的时间步
+func (c *SpeedLimitController) Update(timeStep, timeOfDay int) {
+	changed := false
+	for _, schedule := range c.schedules {
+		active := timeOfDay >= schedule.StartStep && timeOfDay < schedule.EndStep
+		if active != schedule.active {
+			schedule.active = active
+			changed = true
+			log.WriteLog(fmt.Sprintf("Speed Limit Schedule %s: Active %v, Cells %d, Max Speed %d", schedule.Name, active, len(schedule.cells), schedule.MaxSpeed))
+		}
+	}
+
+	if c.rule.Enabled && timeStep%c.rule.Interval == 0 {
+		reduced := c.jamUpstreamCells()
+		if len(reduced) != len(c.reduced) {
+			changed = true
+		} else {
+			for cell := range reduced {
+				if !c.reduced[cell] {
+					changed = true
+					break
+				}
+			}
+		}
+		c.reduced = reduced
+	}
+
+	if changed {
+		c.apply()
+	}
+}
+
+// jamUpstreamCells 返回拥堵单元格上游需要降低限速的单元格
+func (c *SpeedLimitController) jamUpstreamCells() map[element.Cell]bool {
+	reduced := make(map[element.Cell]bool)
+	for _, node := range c.nodes {
+		cell, ok := node.(element.Cell)
+		if !ok || cell.Occupation()/cell.Capacity() < c.rule.JamThreshold {
+			continue
+		}
+		for _, upstream := range detectorCells(c.g, cell, c.rule.UpstreamCells, true) {
+			reduced[upstream] = true
+		}
+	}
+	return reduced
+}
+
+// apply 计算受控单元格的限速并更新，不再受控的单元格恢复原限速
+func (c *SpeedLimitController) apply() {
+	limits := make(map[element.Cell]int)
+	limit := func(cell element.Cell, speed int) {
+		if _, ok := c.base[cell]; !ok {
+			c.base[cell] = cell.MaxSpeed()
+		}
+		if current, ok := limits[cell]; !ok || speed < current {
+			limits[cell] = speed
+		}
+	}
+
+	for _, schedule := range c.schedules {
+		if schedule.active {
+			for _, cell := range schedule.cells {
+				limit(cell, schedule.MaxSpeed)
+			}
+		}
+	}
+	for cell := range c.reduced {
+		limit(cell, c.rule.ReducedSpeed)
+	}
+
+	for cell, base := range c.base {
+		speed := base
+		if l, ok := limits[cell]; ok {
+			speed = min(speed, l)
+		}
+		if cell.MaxSpeed() != speed {
+			cell.SetMaxSpeed(speed)
+		}
+	}
+}
@@ -20,6 +20,8 @@ type SystemState struct {
 	vehiclesOnRoad      map[*element.Vehicle]struct{}
 	averageSpeed        float64
 	density             float64
+	activeIncidents     int
+	numVehiclesDwelling int
 	mu                  sync.RWMutex // 保护并发访问
 }
 
@@ -39,6 +41,8 @@ func (s *SystemState) Update(nodes []graph.Node, numNodes int, avgLane float64)
 	s.numVehicleGenerated, s.numVehiclesActive, s.numVehiclesWaiting, s.numVehicleCompleted = GetVehiclesNum()
 	s.vehiclesOnRoad = GetVehiclesOnRoad(nodes)
 	s.averageSpeed, s.density = GetAverageSpeed_Density(s.vehiclesOnRoad, numNodes, avgLane)
+	s.activeIncidents = GetActiveIncidentCount()
+	s.numVehiclesDwelling = GetDwellingVehicleCount()
 }
 
 // RecordData 记录当前系统状态数据
@@ -48,7 +52,7 @@ func (s *SystemState) RecordData(timeStep int) {
 	defer s.mu.RUnlock()
 
 	recorder.RecordSystemData(timeStep, s.numVehicleGenerated, s.numVehiclesActive,
-		s.numVehiclesWaiting, s.numVehicleCompleted, s.averageSpeed, s.density)
+		s.numVehiclesWaiting, s.numVehicleCompleted, s.averageSpeed, s.density, s.activeIncidents)
 }
 
 // LogStatus 输出系统状态日志
@@ -57,10 +61,10 @@ func (s *SystemState) LogStatus(currentDay int, timeOfDay int) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	log.WriteLog(fmt.Sprintf("Day: %d, TimeOfDay: %v, AvgSpeed: %.2f, Density: %.2f, Generated: %d, Active: %d, OnRoad: %d, Waiting: %d, Completed: %d",
+	log.WriteLog(fmt.Sprintf("Day: %d, TimeOfDay: %v, AvgSpeed: %.2f, Density: %.2f, Generated: %d, Active: %d, OnRoad: %d, Waiting: %d, Dwelling: %d, Completed: %d, Incidents: %d",
 		currentDay, log.ConvertTimeStepToTime(timeOfDay), s.averageSpeed, s.density,
 		s.numVehicleGenerated, s.numVehiclesActive, len(s.vehiclesOnRoad),
-		s.numVehiclesWaiting, s.numVehicleCompleted))
+		s.numVehiclesWaiting, s.numVehiclesDwelling, s.numVehicleCompleted, s.activeIncidents))
 }
 
 // GetVehiclesOnRoadCount 返回当前道路上的车辆数量
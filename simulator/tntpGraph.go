@@ -0,0 +1,273 @@
+package simulator
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"simAndLearning/element"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// tntpLink 表示TNTP路网文件中的一条路段
+type tntpLink struct {
+	from, to     int64
+	capacity     float64 // 通行能力(veh/h)
+	length       float64 // 长度(文件单位)
+	freeFlowTime float64 // 自由流行驶时间(分钟)
+	speed        float64 // 自由流速度(文件长度单位/分钟)
+}
+
+// CreateTNTPGraph 从TNTP格式的路网文件创建路网图
+// TNTP节点保留原编号作为单元格ID，每条路段转换为一串中间单元格，
+// 单元格数量由路段长度/CELL_LENGTH决定，最大速度由自由流速度换算
+// 编号小于<FIRST THRU NODE>的小区形心不允许车辆穿行：形心拆分为只有驶出路段的起点单元格(保留原编号)
+// 和只有驶入路段的终点单元格(编号为最大节点ID+形心编号)
+//
+// 参数:
+//   - filePath: TNTP路网文件路径(*_net.tntp)
+//   - lengthUnit: 文件中路段长度的单位("ft", "mi", "km", "m")
+//   - laneCapacity: 每条车道的通行能力(veh/h)，路段车道数=通行能力/laneCapacity，为0时均为单车道
+//
+// 返回:
+//   - *simple.DirectedGraph: 创建的有向图
+//   - map[int64]graph.Node: 图中所有节点的映射
+//   - map[int64]*element.TrafficLightCell: 红绿灯节点的映射（TNTP不含信号信息，为空）
+//   - error: 如果读取或解析文件失败，返回错误
+func CreateTNTPGraph(filePath string, lengthUnit string, laneCapacity float64) (*simple.DirectedGraph, map[int64]graph.Node, map[int64]*element.TrafficLightCell, error) {
+	links, firstThruNode, err := readTNTPLinks(filePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(links) == 0 {
+		return nil, nil, nil, fmt.Errorf("TNTP文件中没有路段数据: %s", filePath)
+	}
+
+	// 计算每条路段的单元格数量、最大速度和车道数，节点取相邻路段的最大值
+	cellCounts := make([]int, len(links))
+	speeds := make([]int, len(links))
+	lanes := make([]int, len(links))
+	nodeSpeeds := make(map[int64]int)
+	nodeLanes := make(map[int64]int)
+	maxNodeID := int64(0)
+	for i, link := range links {
+		meters := lengthToMeters(link.length, lengthUnit)
+		cellCounts[i] = lengthToCells(meters)
+		speeds[i] = tntpLinkSpeed(link, meters, lengthUnit)
+		lanes[i] = 1
+		if laneCapacity > 0 {
+			lanes[i] = max(int(math.Round(link.capacity/laneCapacity)), 1)
+		}
+
+		for _, id := range []int64{link.from, link.to} {
+			nodeSpeeds[id] = max(nodeSpeeds[id], speeds[i])
+			nodeLanes[id] = max(nodeLanes[id], lanes[i])
+			if id > maxNodeID {
+				maxNodeID = id
+			}
+		}
+	}
+
+	g := simple.NewDirectedGraph()
+	nodes := make(map[int64]graph.Node)
+	lights := make(map[int64]*element.TrafficLightCell)
+	resetIntersections()
+
+	// 创建TNTP节点对应的单元格
+	for id, speed := range nodeSpeeds {
+		cell := element.NewMultiLaneCell(id, speed, float64(nodeLanes[id]), nodeLanes[id])
+		g.AddNode(cell)
+		nodes[id] = cell
+	}
+
+	// 形心的驶入路段连接到单独的终点单元格，车辆不能经形心从驶入路段进入驶出路段
+	sinks := make(map[int64]graph.Node)
+	for id := range nodeSpeeds {
+		if id < firstThruNode {
+			sinkID := maxNodeID + id
+			cell := element.NewMultiLaneCell(sinkID, nodeSpeeds[id], float64(nodeLanes[id]), nodeLanes[id])
+			g.AddNode(cell)
+			nodes[sinkID] = cell
+			sinks[id] = cell
+		}
+	}
+
+	// 中间单元格的ID从最大节点ID和终点单元格之后开始分配
+	nextID := maxNodeID + max(firstThruNode, 1)
+	for i, link := range links {
+		// 路段的最后一个单元格是终点节点本身，只有一个单元格的路段直接连接起终点节点
+		// 起终点相同的路段至少保留一个中间单元格，避免自环
+		intermediateCount := cellCounts[i] - 1
+		if link.from == link.to {
+			intermediateCount = max(intermediateCount, 1)
+		}
+
+		prev := nodes[link.from]
+		for j := 0; j < intermediateCount; j++ {
+			cell := element.NewMultiLaneCell(nextID, speeds[i], float64(lanes[i]), lanes[i])
+			g.AddNode(cell)
+			nodes[nextID] = cell
+			g.SetEdge(simple.Edge{F: prev, T: cell})
+			prev = cell
+			nextID++
+		}
+		to := nodes[link.to]
+		if sink, ok := sinks[link.to]; ok {
+			to = sink
+		}
+		g.SetEdge(simple.Edge{F: prev, T: to})
+	}
+
+	// TNTP路网没有信号节点，配置的配时方案ID均无法匹配
+	if err := applySignalTimings(lights); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 将单元格链划分为路段并注册
+	registerLinks(g)
+
+	// 识别合流单元格并注册
+	registerJunctions(g)
+
+	return g, nodes, lights, nil
+}
+
+// tntpLinkSpeed 计算路段的最大速度（单元格/时间步）
+// 优先使用长度/自由流时间，若自由流时间无效则使用速度列
+func tntpLinkSpeed(link tntpLink, meters float64, lengthUnit string) int {
+	if link.freeFlowTime > 0 {
+		return speedToCellsPerStep(meters / (link.freeFlowTime * 60))
+	}
+	if link.speed > 0 {
+		return speedToCellsPerStep(lengthToMeters(link.speed, lengthUnit) / 60)
+	}
+	return 5
+}
+
+// readTNTPLinks 读取TNTP路网文件中的路段数据和第一个可穿行节点的编号，文件未指定时编号为0
+//
+// 文件格式:
+//
+//	以<...>开头的元数据行，以<END OF METADATA>结束，<FIRST THRU NODE>之前的节点为小区形心
+//	以~开头的注释行，其中包含列名（init_node, term_node, capacity, length, free_flow_time, ... speed ...）
+//	数据行以制表符或空格分隔，以;结尾
+func readTNTPLinks(filePath string) ([]tntpLink, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("打开TNTP文件失败: %v", err)
+	}
+	defer file.Close()
+
+	// 默认列位置，与TNTP标准格式一致
+	columns := map[string]int{
+		"init_node":      0,
+		"term_node":      1,
+		"capacity":       2,
+		"length":         3,
+		"free_flow_time": 4,
+		"speed":          7,
+	}
+
+	links := make([]tntpLink, 0)
+	firstThruNode := int64(0)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if value, ok := strings.CutPrefix(line, "<FIRST THRU NODE>"); ok {
+			if firstThruNode, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64); err != nil {
+				return nil, 0, fmt.Errorf("解析TNTP文件第%d行失败: %v", lineNum, err)
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "<") {
+			continue
+		}
+
+		// 注释行中可能包含列名
+		if strings.HasPrefix(line, "~") {
+			fields := strings.Fields(strings.Trim(line, "~; \t"))
+			if len(fields) > 0 && strings.EqualFold(fields[0], "init_node") {
+				for i, name := range fields {
+					columns[strings.ToLower(name)] = i
+				}
+			}
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimSuffix(line, ";"))
+		link, err := parseTNTPLink(fields, columns)
+		if err != nil {
+			return nil, 0, fmt.Errorf("解析TNTP文件第%d行失败: %v", lineNum, err)
+		}
+		links = append(links, link)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("读取TNTP文件失败: %v", err)
+	}
+
+	return links, firstThruNode, nil
+}
+
+// parseTNTPLink 按列位置解析一行路段数据
+func parseTNTPLink(fields []string, columns map[string]int) (tntpLink, error) {
+	value := func(name string) (float64, error) {
+		i := columns[name]
+		if i >= len(fields) {
+			return 0, fmt.Errorf("缺少%s列", name)
+		}
+		return strconv.ParseFloat(fields[i], 64)
+	}
+
+	var link tntpLink
+	from, err := value("init_node")
+	if err != nil {
+		return link, err
+	}
+	to, err := value("term_node")
+	if err != nil {
+		return link, err
+	}
+	if link.length, err = value("length"); err != nil {
+		return link, err
+	}
+
+	link.from = int64(from)
+	link.to = int64(to)
+
+	// 以下列为可选列，缺失时保持为0
+	link.capacity, _ = value("capacity")
+	link.freeFlowTime, _ = value("free_flow_time")
+	link.speed, _ = value("speed")
+
+	return link, nil
+}
+
+// SaveTNTPGraph 从TNTP文件创建路网并保存到JSON文件
+//
+// 参数:
+//   - tntpPath: TNTP路网文件路径
+//   - lengthUnit: 文件中路段长度的单位
+//   - laneCapacity: 每条车道的通行能力(veh/h)
+//   - filePath: 保存路径
+//
+// 返回:
+//   - *simple.DirectedGraph: 创建的有向图
+//   - map[int64]graph.Node: 图中所有节点的映射
+//   - map[int64]*element.TrafficLightCell: 红绿灯节点的映射
+//   - error: 如果创建或保存过程中发生错误，返回错误
+func SaveTNTPGraph(tntpPath string, lengthUnit string, laneCapacity float64, filePath string) (*simple.DirectedGraph, map[int64]graph.Node, map[int64]*element.TrafficLightCell, error) {
+	g, nodes, lights, err := CreateTNTPGraph(tntpPath, lengthUnit, laneCapacity)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = SaveGraphToJSON(g, nodes, lights, filePath)
+
+	return g, nodes, lights, err
+}
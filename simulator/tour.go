@@ -0,0 +1,214 @@
+package simulator
+
+import (
+	"math"
+	"math/rand/v2"
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"simAndLearning/utils"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// tourPlan 表示封闭车辆到达活动地点后的下一次行程
+type tourPlan struct {
+	tourID  int64      // 下一次行程所属的出行链ID
+	seq     int        // 下一次行程在出行链中的序号
+	dwell   int        // 出发前在当前活动地点的停留时间(时间步)
+	revisit graph.Node // 下一个活动已有的地点，为nil时重新选择终点
+}
+
+// tourState 保存一辆封闭车辆的出行链状态，车辆重新进入系统时保持ID，按ID保存
+type tourState struct {
+	chain     []string              // 当前活动链
+	tourID    int64                 // 当前出行链ID
+	seq       int                   // 最近一次计划行程的序号
+	locations map[string]graph.Node // 已访问活动的地点
+	plan      tourPlan              // 最近一次计划的行程
+}
+
+var (
+	tourStates      map[int64]*tourState = make(map[int64]*tourState)
+	tourStatesMutex sync.Mutex
+	numToursStarted int64
+
+	// dwellingVehicles 保存在活动地点停留的车辆及其出发时间，只在车辆处理的串行阶段访问
+	dwellingVehicles map[*element.Vehicle]int = make(map[*element.Vehicle]int)
+)
+
+// toursEnabled 返回是否为封闭车辆配置了出行链
+func toursEnabled() bool {
+	cfg := config.GetConfig()
+	return cfg != nil && len(cfg.Vehicle.Tours.Chains) > 0
+}
+
+// GetDwellingVehicleCount 返回在活动地点停留、尚未出发的车辆数量
+func GetDwellingVehicleCount() int {
+	return len(dwellingVehicles)
+}
+
+// startTour 为封闭车辆选择活动链并开始新的出行链，origin为车辆当前所在的地点
+// 未配置出行链时不做处理
+func startTour(vehicle *element.Vehicle, origin graph.Node) {
+	if !toursEnabled() {
+		return
+	}
+
+	tourStatesMutex.Lock()
+	defer tourStatesMutex.Unlock()
+
+	state := &tourState{locations: make(map[string]graph.Node)}
+	state.begin(origin, "")
+	state.plan = tourPlan{tourID: state.tourID, seq: state.seq}
+	tourStates[vehicle.Index()] = state
+	vehicle.SetTour(state.tourID, state.seq)
+}
+
+// begin 按份额选择以当前活动开始的新活动链，车辆从活动链的第一个活动出发
+// 非固定地点的活动在新的出行链中重新选择地点
+//
+// 参数:
+//   - origin: 车辆当前所在的地点
+//   - current: 车辆当前所在的活动，为空时(车辆首次开始出行链)可以选择任意活动链
+func (s *tourState) begin(origin graph.Node, current string) {
+	activities := config.GetConfig().Vehicle.Tours.Activities
+	for name := range s.locations {
+		if !activities[name].FixedLocation {
+			delete(s.locations, name)
+		}
+	}
+
+	s.chain = sampleTourChain(current)
+	s.tourID = atomic.AddInt64(&numToursStarted, 1)
+	s.seq = 1
+	s.locations[s.chain[0]] = origin
+}
+
+// planTourTrip 在封闭车辆到达活动地点后计划出行链中的下一次行程
+// 车辆完成活动链后在最后一个活动停留，之后开始新的出行链；
+// 同一次到达重复调用时返回相同的计划，车辆因找不到路径等原因推迟出发时不会跳过活动
+//
+// 参数:
+//   - vehicle: 完成行程的车辆
+//
+// 返回:
+//   - tourPlan: 下一次行程的计划
+func planTourTrip(vehicle *element.Vehicle) tourPlan {
+	tourStatesMutex.Lock()
+	defer tourStatesMutex.Unlock()
+
+	state, ok := tourStates[vehicle.Index()]
+	if !ok {
+		// 车辆在启用出行链之前生成，从到达地点开始新的出行链
+		state = &tourState{locations: make(map[string]graph.Node)}
+		state.begin(vehicle.Destination(), "")
+		state.plan = tourPlan{tourID: state.tourID, seq: state.seq, revisit: state.locations[state.chain[1]]}
+		tourStates[vehicle.Index()] = state
+		return state.plan
+	}
+
+	tourID, seq := vehicle.Tour()
+	if tourID != state.tourID || seq != state.seq {
+		return state.plan
+	}
+
+	arrived := state.chain[seq]
+	state.locations[arrived] = vehicle.Destination()
+	dwell := sampleDwell(arrived)
+
+	if seq == len(state.chain)-1 {
+		state.begin(vehicle.Destination(), arrived)
+	} else {
+		state.seq++
+	}
+
+	state.plan = tourPlan{
+		tourID:  state.tourID,
+		seq:     state.seq,
+		dwell:   dwell,
+		revisit: state.locations[state.chain[state.seq]],
+	}
+	return state.plan
+}
+
+// revisitDestination 返回前往已访问活动地点的终点
+// 配置了交通小区时前往该地点所属小区的出口单元格，终点与起点相同时返回nil
+func revisitDestination(origin, location graph.Node) graph.Node {
+	destination := location
+	if zoneID, ok := ZoneOf(location); ok && len(zones[zoneID].exits) > 0 {
+		destination = zones[zoneID].exitCell()
+	}
+	if destination.ID() == origin.ID() {
+		return nil
+	}
+	return destination
+}
+
+// sampleTourChain 在以指定活动开始的活动链中按份额随机选择一条，start为空时在所有活动链中选择
+// 配置检查保证每条活动链的最后一个活动都有可选的活动链
+func sampleTourChain(start string) []string {
+	chains := make([]config.TourChainConfig, 0)
+	total := 0.0
+	for _, chain := range config.GetConfig().Vehicle.Tours.Chains {
+		if start == "" || chain.Activities[0] == start {
+			chains = append(chains, chain)
+			total += chain.Share
+		}
+	}
+	r := rand.Float64() * total
+	for _, chain := range chains {
+		if r -= chain.Share; r < 0 {
+			return chain.Activities
+		}
+	}
+	return chains[len(chains)-1].Activities
+}
+
+// sampleDwell 按活动的停留时间分布抽样停留时间(时间步)，未配置的活动不停留
+func sampleDwell(activity string) int {
+	cfg, ok := config.GetConfig().Vehicle.Tours.Activities[activity]
+	if !ok {
+		return 0
+	}
+
+	var dwell float64
+	switch cfg.Distribution {
+	case "uniform":
+		dwell = cfg.Mean - cfg.Std + rand.Float64()*2*cfg.Std
+	case "normal":
+		dwell = cfg.Mean + rand.NormFloat64()*cfg.Std
+	case "exponential":
+		dwell = rand.ExpFloat64() * cfg.Mean
+	default:
+		dwell = cfg.Mean
+	}
+	return max(int(math.Round(dwell)), cfg.Min)
+}
+
+// releaseDwellingVehicles 让停留时间结束的车辆出发，按出发时的路况计算路径并放入起点缓冲区
+// 找不到路径的车辆继续停留，在下一个时间步重试
+func releaseDwellingVehicles(simTime int, g *simple.DirectedGraph) {
+	if len(dwellingVehicles) == 0 {
+		return
+	}
+
+	due := make([]*element.Vehicle, 0)
+	for vehicle, departure := range dwellingVehicles {
+		if departure <= simTime {
+			due = append(due, vehicle)
+		}
+	}
+	// 按车辆ID排序，保证同一时间步出发的车辆进入缓冲区的顺序稳定
+	sort.Slice(due, func(i, j int) bool { return due[i].Index() < due[j].Index() })
+
+	pathFinder := utils.GetPathFinder()
+	for _, vehicle := range due {
+		if departVehicle(vehicle, g, simTime, pathFinder) {
+			delete(dwellingVehicles, vehicle)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package simulator
 import (
 	"math"
 	"simAndLearning/config"
+	"simAndLearning/utils"
 
 	"math/rand/v2"
 
 	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
 )
 
 // 定义常量以提高代码可维护性
@@ -184,6 +186,28 @@ func TripDistanceRange() (int, int) {
 	return minLength, maxLength
 }
 
+// sampleTripDestination 为从起点出发的行程随机选择终点
+// 配置了经验出行距离分布时按分布选择网络距离相近的终点，否则在距离范围内的可达单元格中随机选择；
+// 不启用距离限制时也确保最小距离在1英里以上
+//
+// 返回:
+//   - graph.Node: 选择的终点
+//   - bool: 是否找到合适的终点
+func sampleTripDestination(g *simple.DirectedGraph, origin graph.Node) (graph.Node, bool) {
+	if tripLengthDistribution != nil {
+		return tripLengthDistribution.destination(g, origin)
+	}
+
+	minLength, maxLength := TripDistanceRange()
+	allowedDCells := utils.AccessibleNodesWithinRange(g, origin, minLength, maxLength)
+	if len(allowedDCells) == 0 {
+		return nil, false
+	}
+
+	// 从可达节点中随机选择一个作为终点
+	return allowedDCells[rand.IntN(len(allowedDCells))], true
+}
+
 // GetRandomDestination 从所有节点中随机选择目的地
 // 当不启用距离限制时使用
 func GetRandomDestination(nodes []graph.Node, excludeNode graph.Node) graph.Node {
@@ -0,0 +1,194 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"simAndLearning/config"
+	"simAndLearning/utils"
+	"sort"
+	"strconv"
+	"sync"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// TripLengthDistribution 表示从文件读取的经验出行距离分布
+// 车辆按分布抽样目标距离，再选择网络距离与目标距离相近的终点
+type TripLengthDistribution struct {
+	x         []float64 // 出行距离(文件中的单位)
+	cdf       []float64 // 对应的累积概率，单调不减，最后一个值为1
+	cellsPer  float64   // 每单位距离的单元格数
+	tolerance float64   // 终点网络距离与目标距离的相对误差上限
+	attempts  int       // 重新抽样目标距离的最大次数
+}
+
+var (
+	// tripLengthDistribution 保存当前使用的经验出行距离分布，未配置时为nil
+	tripLengthDistribution *TripLengthDistribution
+
+	// realizedTripLengths 保存上次统计以来所选终点的网络距离(文件中的单位)，用于检验与输入分布的拟合程度
+	realizedTripLengths      []float64
+	realizedTripLengthsMutex sync.Mutex
+)
+
+// LoadTripLengthDistribution 按配置读取经验出行距离分布文件，未配置文件时不使用经验分布
+//
+// 返回:
+//   - *TripLengthDistribution: 读取的分布，未配置时为nil
+//   - error: 如果读取或解析文件失败，返回错误
+func LoadTripLengthDistribution() (*TripLengthDistribution, error) {
+	tripLengthDistribution = nil
+	cfg := config.GetConfig()
+	if cfg == nil || cfg.TripDistance.Empirical.FilePath == "" {
+		return nil, nil
+	}
+	empirical := cfg.TripDistance.Empirical
+
+	records, err := readCSVRecords(empirical.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([][2]float64, 0, len(records))
+	for i, record := range records {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("%s 第%d行字段不足", empirical.FilePath, i+2)
+		}
+		xi, err1 := strconv.ParseFloat(record[0], 64)
+		fi, err2 := strconv.ParseFloat(record[1], 64)
+		if err1 != nil || err2 != nil || xi < 0 || fi < 0 {
+			return nil, fmt.Errorf("%s 第%d行数据无效", empirical.FilePath, i+2)
+		}
+		points = append(points, [2]float64{xi, fi})
+	}
+
+	// 从图中数字化的数据点可能略有乱序，按出行距离排序
+	sort.SliceStable(points, func(i, j int) bool { return points[i][0] < points[j][0] })
+	x := make([]float64, len(points))
+	f := make([]float64, len(points))
+	for i, point := range points {
+		x[i], f[i] = point[0], point[1]
+	}
+
+	cdf := f
+	if empirical.Kind == "pdf" {
+		// 按梯形法积分概率密度
+		cdf = make([]float64, len(f))
+		for i := 1; i < len(f); i++ {
+			cdf[i] = cdf[i-1] + (f[i]+f[i-1])/2*(x[i]-x[i-1])
+		}
+	} else {
+		// 数字化的累积分布可能有小幅回落，取累计最大值保证单调
+		for i := 1; i < len(cdf); i++ {
+			cdf[i] = math.Max(cdf[i], cdf[i-1])
+		}
+	}
+	total := cdf[len(cdf)-1]
+	if total <= 0 {
+		return nil, fmt.Errorf("%s 中的分布总和为0", empirical.FilePath)
+	}
+	for i := range cdf {
+		cdf[i] /= total
+	}
+
+	cellsPer := MILE_TO_KM * 1000 / CELL_LENGTH
+	if empirical.Unit == "km" {
+		cellsPer = 1000 / CELL_LENGTH
+	}
+
+	tripLengthDistribution = &TripLengthDistribution{
+		x:         x,
+		cdf:       cdf,
+		cellsPer:  cellsPer,
+		tolerance: empirical.Tolerance,
+		attempts:  empirical.MaxAttempts,
+	}
+	return tripLengthDistribution, nil
+}
+
+// Sample 按分布的逆累积分布函数抽样一个出行距离(文件中的单位)，在相邻点之间线性插值
+func (d *TripLengthDistribution) Sample() float64 {
+	u := rand.Float64()
+	i := sort.SearchFloat64s(d.cdf, u)
+	if i == 0 {
+		return d.x[0]
+	}
+	if i >= len(d.cdf) {
+		return d.x[len(d.x)-1]
+	}
+	frac := (u - d.cdf[i-1]) / (d.cdf[i] - d.cdf[i-1])
+	return d.x[i-1] + frac*(d.x[i]-d.x[i-1])
+}
+
+// CDF 返回出行距离不超过x(文件中的单位)的概率
+func (d *TripLengthDistribution) CDF(x float64) float64 {
+	i := sort.SearchFloat64s(d.x, x)
+	if i == 0 {
+		if x < d.x[0] {
+			return 0
+		}
+		return d.cdf[0]
+	}
+	if i >= len(d.x) {
+		return 1
+	}
+	frac := (x - d.x[i-1]) / (d.x[i] - d.x[i-1])
+	return d.cdf[i-1] + frac*(d.cdf[i]-d.cdf[i-1])
+}
+
+// Mean 返回分布的均值(文件中的单位)
+func (d *TripLengthDistribution) Mean() float64 {
+	mean := d.x[0] * d.cdf[0]
+	for i := 1; i < len(d.x); i++ {
+		mean += (d.x[i] + d.x[i-1]) / 2 * (d.cdf[i] - d.cdf[i-1])
+	}
+	return mean
+}
+
+// destination 抽样目标距离，并在网络距离与目标距离的相对误差不超过容许误差的单元格中随机选择终点
+// 没有满足条件的单元格时重新抽样，达到最大次数后返回false
+func (d *TripLengthDistribution) destination(g *simple.DirectedGraph, origin graph.Node) (graph.Node, bool) {
+	for attempt := 0; attempt < d.attempts; attempt++ {
+		target := d.Sample() * d.cellsPer
+		lower := max(int(math.Ceil(target*(1-d.tolerance))), 1)
+		upper := max(int(math.Floor(target*(1+d.tolerance))), lower)
+
+		nodes, distances := utils.NodesWithinDistance(g, origin, lower, upper)
+		if len(nodes) == 0 {
+			continue
+		}
+
+		i := rand.IntN(len(nodes))
+		realizedTripLengthsMutex.Lock()
+		realizedTripLengths = append(realizedTripLengths, float64(distances[i])/d.cellsPer)
+		realizedTripLengthsMutex.Unlock()
+		return nodes[i], true
+	}
+	return nil, false
+}
+
+// TripLengthFit 返回上次调用以来所选终点的数量、网络距离的均值(文件中的单位)，
+// 以及网络距离的经验分布与输入分布之间的Kolmogorov-Smirnov距离，并清空统计
+// 未使用经验分布或没有行程时返回0
+func TripLengthFit() (int, float64, float64) {
+	realizedTripLengthsMutex.Lock()
+	lengths := realizedTripLengths
+	realizedTripLengths = nil
+	realizedTripLengthsMutex.Unlock()
+
+	if tripLengthDistribution == nil || len(lengths) == 0 {
+		return 0, 0, 0
+	}
+
+	sort.Float64s(lengths)
+	n := float64(len(lengths))
+	sum, ks := 0.0, 0.0
+	for i, length := range lengths {
+		sum += length
+		f := tripLengthDistribution.CDF(length)
+		ks = math.Max(ks, math.Max(float64(i+1)/n-f, f-float64(i)/n))
+	}
+	return len(lengths), sum / n, ks
+}
@@ -0,0 +1,277 @@
+package simulator
+
+import (
+	"fmt"
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"simAndLearning/recorder"
+	"simAndLearning/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// 行程清单的模式
+const (
+	TRIP_LIST_NONE   = "none"   // 不使用行程清单
+	TRIP_LIST_WRITE  = "write"  // 将生成的行程写入行程清单
+	TRIP_LIST_REPLAY = "replay" // 回放行程清单中的行程，代替随机生成
+)
+
+// replayTrip 表示行程清单中的一次行程
+type replayTrip struct {
+	departure    int
+	id           int64
+	seq          int
+	flag         bool
+	class        string
+	maxSpeed     int
+	velocity     int
+	acceleration int
+	occupy       float64
+	slowingProb  float64
+	rerouting    bool
+	dwell        int
+	tourID       int64
+	tourSeq      int
+	origin       graph.Node
+	destination  graph.Node
+	path         []graph.Node
+}
+
+var (
+	// replayTrips 保存回放的行程，按出发时间步分组，组内按车辆ID排列
+	// 封闭车辆只包含第一次行程
+	replayTrips map[int][]replayTrip = make(map[int][]replayTrip)
+
+	// replayClosedTrips 保存封闭车辆第一次之后的行程，按车辆ID分组，组内按行程序号排列
+	// 车辆完成上一次行程后依次取出
+	replayClosedTrips map[int64][]replayTrip = make(map[int64][]replayTrip)
+
+	// replayPaths 保存回放时使用文件中路径的车辆尚未使用的记录路径，出发时取出
+	replayPaths map[*element.Vehicle][]graph.Node = make(map[*element.Vehicle][]graph.Node)
+
+	// tripSeqs 保存写入模式下每辆车已记录的行程数
+	tripSeqs      map[int64]int = make(map[int64]int)
+	tripSeqsMutex sync.Mutex
+
+	// closedTripDwells 保存写入模式下尚未出发的封闭车辆行程的计划停留时间，出发时记录行程
+	closedTripDwells map[*element.Vehicle]int = make(map[*element.Vehicle]int)
+)
+
+// TripListMode 返回行程清单的模式，未加载配置时不使用行程清单
+func TripListMode() string {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return TRIP_LIST_NONE
+	}
+	return cfg.Demand.TripList.Mode
+}
+
+// recordTrip 在写入模式下记录新生成的行程，并按车辆ID为行程编号
+// 计划车辆和封闭车辆的第一次行程在生成时记录，封闭车辆之后的行程在出发时记录
+//
+// 参数:
+//   - vehicle: 已设置路径的车辆
+//   - departure: 出发时间步
+//   - dwell: 出发前在起点的计划停留时间
+func recordTrip(vehicle *element.Vehicle, departure, dwell int) {
+	if TripListMode() != TRIP_LIST_WRITE {
+		return
+	}
+
+	tripSeqsMutex.Lock()
+	tripSeqs[vehicle.Index()]++
+	seq := tripSeqs[vehicle.Index()]
+	tripSeqsMutex.Unlock()
+
+	recorder.RecordTrip(vehicle, departure, seq, dwell)
+}
+
+// holdClosedTrip 在写入模式下保存封闭车辆下一次行程的计划停留时间，车辆出发时再记录行程
+func holdClosedTrip(vehicle *element.Vehicle, dwell int) {
+	if TripListMode() == TRIP_LIST_WRITE {
+		closedTripDwells[vehicle] = dwell
+	}
+}
+
+// recordDeparture 在封闭车辆出发时记录由holdClosedTrip保存的行程
+func recordDeparture(vehicle *element.Vehicle, simTime int) {
+	if dwell, ok := closedTripDwells[vehicle]; ok {
+		delete(closedTripDwells, vehicle)
+		recordTrip(vehicle, simTime, dwell)
+	}
+}
+
+// takeRecordedPath 取出回放时为车辆保存的记录路径，没有记录路径时返回false
+func takeRecordedPath(vehicle *element.Vehicle) ([]graph.Node, bool) {
+	path, ok := replayPaths[vehicle]
+	delete(replayPaths, vehicle)
+	return path, ok
+}
+
+// LoadTripList 在回放模式下读取行程清单，行程中的单元格和路段在当前路网中查找
+//
+// 参数:
+//   - g: 路网图
+//
+// 返回:
+//   - int: 读取的行程数，非回放模式时为0
+//   - error: 如果读取或解析文件失败，返回错误
+func LoadTripList(g *simple.DirectedGraph) (int, error) {
+	replayTrips = make(map[int][]replayTrip)
+	replayClosedTrips = make(map[int64][]replayTrip)
+	replayPaths = make(map[*element.Vehicle][]graph.Node)
+	if TripListMode() != TRIP_LIST_REPLAY {
+		return 0, nil
+	}
+
+	filePath := config.GetConfig().Demand.TripList.FilePath
+	records, err := readCSVRecords(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, record := range records {
+		trip, err := parseTrip(record, g)
+		if err != nil {
+			return 0, fmt.Errorf("%s 第%d行: %v", filePath, i+2, err)
+		}
+		if trip.flag && trip.seq > 1 {
+			replayClosedTrips[trip.id] = append(replayClosedTrips[trip.id], trip)
+			continue
+		}
+		replayTrips[trip.departure] = append(replayTrips[trip.departure], trip)
+	}
+	for _, trips := range replayTrips {
+		sort.Slice(trips, func(i, j int) bool { return trips[i].id < trips[j].id })
+	}
+	for _, trips := range replayClosedTrips {
+		sort.Slice(trips, func(i, j int) bool { return trips[i].seq < trips[j].seq })
+	}
+	return len(records), nil
+}
+
+// parseTrip 解析行程清单中的一行，字段顺序与recorder.InitTripListCSV的标题一致
+func parseTrip(record []string, g *simple.DirectedGraph) (replayTrip, error) {
+	if len(record) < 17 {
+		return replayTrip{}, fmt.Errorf("字段不足")
+	}
+
+	trip := replayTrip{class: record[4]}
+	var errs [14]error
+	trip.departure, errs[0] = strconv.Atoi(record[0])
+	trip.id, errs[1] = strconv.ParseInt(record[1], 10, 64)
+	trip.seq, errs[2] = strconv.Atoi(record[2])
+	trip.flag, errs[3] = strconv.ParseBool(record[3])
+	trip.maxSpeed, errs[4] = strconv.Atoi(record[5])
+	trip.velocity, errs[5] = strconv.Atoi(record[6])
+	trip.acceleration, errs[6] = strconv.Atoi(record[7])
+	trip.occupy, errs[7] = strconv.ParseFloat(record[8], 64)
+	trip.slowingProb, errs[8] = strconv.ParseFloat(record[9], 64)
+	trip.rerouting, errs[9] = strconv.ParseBool(record[10])
+	trip.dwell, errs[10] = strconv.Atoi(record[11])
+	trip.tourID, errs[11] = strconv.ParseInt(record[12], 10, 64)
+	trip.tourSeq, errs[12] = strconv.Atoi(record[13])
+	for _, err := range errs {
+		if err != nil {
+			return replayTrip{}, fmt.Errorf("数据无效: %v", err)
+		}
+	}
+
+	ids := []string{record[14], record[15]}
+	if path := strings.Trim(record[16], "[]"); path != "" {
+		ids = append(ids, strings.Split(path, ",")...)
+	}
+	nodes := make([]graph.Node, len(ids))
+	for i, id := range ids {
+		nodeID, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+		if err != nil {
+			return replayTrip{}, fmt.Errorf("单元格ID无效: %s", id)
+		}
+		if nodes[i] = g.Node(nodeID); nodes[i] == nil {
+			return replayTrip{}, fmt.Errorf("单元格 %d 不存在", nodeID)
+		}
+	}
+	trip.origin, trip.destination, trip.path = nodes[0], nodes[1], nodes[2:]
+	return trip, nil
+}
+
+// ReplayTrips 回放在当前时间步出发的行程，按文件中的车辆参数创建车辆并放入起点缓冲区
+// 配置使用文件中的路径时直接使用记录的路径，否则按当前的路径查找方法重新计算
+// 封闭车辆之后的行程由replayClosedTrip在车辆完成上一次行程时回放
+//
+// 参数:
+//   - simTime: 当前模拟时间
+//   - g: 路网图
+//
+// 返回:
+//   - int: 放入缓冲区的车辆数
+func ReplayTrips(simTime int, g *simple.DirectedGraph) int {
+	trips := replayTrips[simTime]
+	if len(trips) == 0 {
+		return 0
+	}
+
+	pathFinder := utils.GetPathFinder()
+
+	replayed := 0
+	for _, trip := range trips {
+		vehicle := newReplayVehicle(trip)
+		atomic.AddInt64(&numVehicleGenerated, 1)
+
+		if ok, err := vehicle.SetOD(g, trip.origin, trip.destination); !ok || err != nil {
+			continue
+		}
+		setVehicleZones(vehicle, trip.origin, trip.destination)
+
+		if departVehicle(vehicle, g, simTime, pathFinder) {
+			replayed++
+		}
+	}
+
+	// 回放后不再需要该时间步的行程
+	delete(replayTrips, simTime)
+	return replayed
+}
+
+// replayClosedTrip 回放完成行程的封闭车辆在行程清单中的下一次行程，代替随机选择终点和出行链
+// 车辆在起点停留记录的时间后出发，行程清单中没有更多行程时车辆退出系统
+func replayClosedTrip(vehicle *element.Vehicle, simTime int, g *simple.DirectedGraph, pathFinder utils.PathFinder) {
+	trips := replayClosedTrips[vehicle.Index()]
+	if len(trips) == 0 {
+		retireClosedVehicle(vehicle, "no recorded trip")
+		return
+	}
+	trip := trips[0]
+	replayClosedTrips[vehicle.Index()] = trips[1:]
+
+	newVehicle := newReplayVehicle(trip)
+	if ok, err := newVehicle.SetOD(g, trip.origin, trip.destination); !ok || err != nil {
+		retireClosedVehicle(vehicle, "invalid OD")
+		return
+	}
+	setVehicleZones(newVehicle, trip.origin, trip.destination)
+
+	if trip.dwell > 0 || !departVehicle(newVehicle, g, simTime, pathFinder) {
+		dwellingVehicles[newVehicle] = simTime + max(trip.dwell, 1)
+	}
+}
+
+// newReplayVehicle 按行程清单中的车辆参数和出行链创建车辆
+// 配置使用文件中的路径时保存记录的路径，车辆出发时使用
+func newReplayVehicle(trip replayTrip) *element.Vehicle {
+	vehicle := element.NewVehicle(trip.id, trip.velocity, trip.acceleration, trip.occupy, trip.slowingProb, trip.flag)
+	vehicle.SetClass(trip.class, trip.maxSpeed)
+	vehicle.SetRerouting(trip.rerouting)
+	vehicle.SetTour(trip.tourID, trip.tourSeq)
+	if config.GetConfig().Demand.TripList.UseRecordedPaths {
+		replayPaths[vehicle] = trip.path
+	}
+	return vehicle
+}
@@ -0,0 +1,49 @@
+package simulator
+
+import (
+	"math"
+	"simAndLearning/config"
+)
+
+const (
+	// 英尺到米的转换系数
+	FOOT_TO_M float64 = 0.3048
+
+	// 一天的秒数
+	SECONDS_PER_DAY float64 = 86400
+)
+
+// secondsPerTimeStep 返回一个时间步对应的秒数
+// 由配置中的oneDayTimeSteps推算，默认57600步/天，即1.5秒/步
+func secondsPerTimeStep() float64 {
+	cfg := config.GetConfig()
+	if cfg == nil || cfg.Simulation.OneDayTimeSteps <= 0 {
+		return SECONDS_PER_DAY / 57600
+	}
+	return SECONDS_PER_DAY / float64(cfg.Simulation.OneDayTimeSteps)
+}
+
+// lengthToMeters 按单位将长度转换为米
+// 支持的单位: "m", "km", "ft", "mi"，未知单位按米处理
+func lengthToMeters(length float64, unit string) float64 {
+	switch unit {
+	case "km":
+		return length * 1000
+	case "ft":
+		return length * FOOT_TO_M
+	case "mi":
+		return length * MILE_TO_KM * 1000
+	default:
+		return length
+	}
+}
+
+// lengthToCells 将以米为单位的长度转换为单元格数量，至少为1
+func lengthToCells(meters float64) int {
+	return max(int(math.Round(meters/CELL_LENGTH)), 1)
+}
+
+// speedToCellsPerStep 将以米/秒为单位的速度转换为每时间步通过的单元格数，至少为1
+func speedToCellsPerStep(metersPerSecond float64) int {
+	return max(int(math.Round(metersPerSecond*secondsPerTimeStep()/CELL_LENGTH)), 1)
+}
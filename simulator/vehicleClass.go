@@ -0,0 +1,68 @@
+package simulator
+
+import (
+	"math/rand/v2"
+	"simAndLearning/config"
+	"simAndLearning/element"
+)
+
+// defaultVehicleClass 未加载配置时使用的车辆类别，与原有的随机车辆参数一致
+var defaultVehicleClass = config.VehicleClassConfig{
+	Name:            "car",
+	Share:           1.0,
+	Occupy:          1.0,
+	Acceleration:    [2]int{1, 3},
+	InitialVelocity: [2]int{1, 2},
+	SlowingProb:     [2]float64{0, 0.5},
+}
+
+// sampleVehicleClass 按车队组成中的份额随机选择车辆类别
+func sampleVehicleClass() config.VehicleClassConfig {
+	cfg := config.GetConfig()
+	if cfg == nil || len(cfg.Vehicle.Classes) == 0 {
+		return defaultVehicleClass
+	}
+
+	classes := cfg.Vehicle.Classes
+	total := 0.0
+	for _, class := range classes {
+		total += class.Share
+	}
+
+	r := rand.Float64() * total
+	for _, class := range classes {
+		if r < class.Share {
+			return class
+		}
+		r -= class.Share
+	}
+	return classes[len(classes)-1]
+}
+
+// newClassVehicle 按车辆类别的参数分布创建新车辆
+//
+// 参数:
+//   - index: 车辆ID
+//   - class: 车辆类别
+//   - flag: 是否为封闭车辆
+//
+// 返回:
+//   - *element.Vehicle: 创建的车辆
+func newClassVehicle(index int64, class config.VehicleClassConfig, flag bool) *element.Vehicle {
+	vehicle := element.NewVehicle(
+		index,
+		randomIntInRange(class.InitialVelocity),
+		randomIntInRange(class.Acceleration),
+		class.Occupy,
+		class.SlowingProb[0]+rand.Float64()*(class.SlowingProb[1]-class.SlowingProb[0]),
+		flag,
+	)
+	vehicle.SetClass(class.Name, class.MaxSpeed)
+	vehicle.SetRerouting(sampleRerouting())
+	return vehicle
+}
+
+// randomIntInRange 在闭区间[r[0], r[1]]内随机选择一个整数
+func randomIntInRange(r [2]int) int {
+	return r[0] + rand.IntN(r[1]-r[0]+1)
+}
@@ -1,7 +1,6 @@
 package simulator
 
 import (
-	"simAndLearning/element"
 	"simAndLearning/utils"
 	"sync"
 	"sync/atomic"
@@ -18,21 +17,6 @@ func getNextVehicleID() int64 {
 	return atomic.AddInt64(&numVehicleGenerated, 1)
 }
 
-// randomVelocity 生成随机初始速度 (1-3)
-func randomVelocity() int {
-	return 1 + rand.IntN(2)
-}
-
-// randomAcceleration 生成随机加速度 (1-4)
-func randomAcceleration() int {
-	return 1 + rand.IntN(3)
-}
-
-// randomSlowingProbability 生成随机减速概率 (0-0.5)
-func randomSlowingProbability() float64 {
-	return rand.Float64() / 2.0
-}
-
 // InitFixedVehicle 初始化固定数量的车辆
 // 创建n个闭环车辆并将其添加到等待队列
 // params:
@@ -54,50 +38,36 @@ func InitFixedVehicle(n int, g *simple.DirectedGraph, nodes []graph.Node) {
 		go func() {
 			defer wg.Done()
 
-			// 从nodes中随机选择一个作为起点
-			oCell := nodes[rand.IntN(len(nodes))]
-
-			// 根据是否启用距离限制选择不同的方式获取终点
-			var dCell graph.Node
-			if isDistanceLimitEnabled() {
-				// 获取合适距离范围内的终点
-				minLength, maxLength := TripDistanceRange()
-				allowedDCells := utils.AccessibleNodesWithinRange(g, oCell, minLength, maxLength)
-
-				// 如果没有合适的终点，返回
-				if len(allowedDCells) == 0 {
+			var oCell, dCell graph.Node
+			if len(zones) > 0 {
+				// 配置了交通小区时从小区进口单元格进入，在小区出口单元格离开
+				var ok bool
+				if oCell, dCell, ok = sampleZoneOD(-1); !ok {
 					return
 				}
-
-				// 从可达节点中随机选择一个作为终点
-				dCell = allowedDCells[rand.IntN(len(allowedDCells))]
 			} else {
-				// 即使不启用距离限制，也确保最小距离在1英里以上
-				minLength, _ := TripDistanceRange() // 使用TripDistanceRange获取最小距离，已确保大于1英里
-				allowedDCells := utils.AccessibleNodesWithinRange(g, oCell, minLength, 1000000)
-				if len(allowedDCells) == 0 {
+				// 从nodes中随机选择一个作为起点
+				oCell = nodes[rand.IntN(len(nodes))]
+
+				// 按出行距离分布选择终点
+				var ok bool
+				if dCell, ok = sampleTripDestination(g, oCell); !ok {
 					return // 如果没有合适的终点，返回
 				}
-
-				// 从可达节点中随机选择一个作为终点
-				dCell = allowedDCells[rand.IntN(len(allowedDCells))]
 			}
 
-			// 创建新车辆
-			vehicle := element.NewVehicle(
-				getNextVehicleID(),
-				randomVelocity(),
-				randomAcceleration(),
-				1.0, // 车辆长度
-				randomSlowingProbability(),
-				true, // ClosedVehicle = true，循环行驶
-			)
+			// 按车队组成随机选择类别并创建新车辆
+			vehicle := newClassVehicle(getNextVehicleID(), sampleVehicleClass(), true) // ClosedVehicle = true，循环行驶
 
 			// 设置起点和终点
 			ok, err := vehicle.SetOD(g, oCell, dCell)
 			if !ok || err != nil {
 				return // 设置失败，跳过此车辆
 			}
+			setVehicleZones(vehicle, oCell, dCell)
+
+			// 配置了出行链时从起点开始第一条出行链
+			startTour(vehicle, oCell)
 
 			// 计算路径（使用配置的路径查找方法）
 			path, _, err := pathFinder(g, oCell, dCell)
@@ -105,12 +75,18 @@ func InitFixedVehicle(n int, g *simple.DirectedGraph, nodes []graph.Node) {
 				return // 路径计算失败，跳过此车辆
 			}
 
+			// 根据以往经历的行程时间在已知路径和候选路径之间选择
+			path = chooseLearnedPath(vehicle.Index(), oCell, dCell, path)
+
 			// 设置路径
 			ok, err = vehicle.SetPath(path)
 			if !ok || err != nil {
 				return // 路径设置失败，跳过此车辆
 			}
 
+			// 写入模式下记录行程
+			recordTrip(vehicle, 0, 0)
+
 			// 将车辆加入缓冲区
 			vehicle.BufferIn(0)
 
@@ -123,9 +99,7 @@ func InitFixedVehicle(n int, g *simple.DirectedGraph, nodes []graph.Node) {
 			atomic.AddInt64(&numVehiclesWaiting, 1)
 
 			// 更新车辆激活状态
-			if vehicle.UpdateActiveState() {
-				vehicle.SystemIn()
-
+			if vehicle.UpdateActiveState() && vehicle.SystemIn(0) {
 				waitingVehiclesMutex.Lock()
 				delete(waitingVehicles, vehicle)
 				waitingVehiclesMutex.Unlock()
@@ -162,50 +136,33 @@ func GenerateScheduleVehicle(simTime, n int, g *simple.DirectedGraph, nodes []gr
 		go func() {
 			defer wg.Done()
 
-			// 从nodes中随机选择一个作为起点
-			oCell := nodes[rand.IntN(len(nodes))]
-
-			// 根据是否启用距离限制选择不同的方式获取终点
-			var dCell graph.Node
-			if isDistanceLimitEnabled() {
-				// 获取合适距离范围内的终点
-				minLength, maxLength := TripDistanceRange()
-				allowedDCells := utils.AccessibleNodesWithinRange(g, oCell, minLength, maxLength)
-
-				// 如果没有合适的终点，返回
-				if len(allowedDCells) == 0 {
+			var oCell, dCell graph.Node
+			if len(zones) > 0 {
+				// 配置了交通小区时从小区进口单元格进入，在小区出口单元格离开，有出行需求矩阵时按矩阵选择小区
+				var ok bool
+				if oCell, dCell, ok = sampleZoneOD(-1); !ok {
 					return
 				}
-
-				// 从可达节点中随机选择一个作为终点
-				dCell = allowedDCells[rand.IntN(len(allowedDCells))]
 			} else {
-				// 即使不启用距离限制，也确保最小距离在1英里以上
-				minLength, _ := TripDistanceRange() // 使用TripDistanceRange获取最小距离，已确保大于1英里
-				allowedDCells := utils.AccessibleNodesWithinRange(g, oCell, minLength, 1000000)
-				if len(allowedDCells) == 0 {
+				// 从nodes中随机选择一个作为起点
+				oCell = nodes[rand.IntN(len(nodes))]
+
+				// 按出行距离分布选择终点
+				var ok bool
+				if dCell, ok = sampleTripDestination(g, oCell); !ok {
 					return // 如果没有合适的终点，返回
 				}
-
-				// 从可达节点中随机选择一个作为终点
-				dCell = allowedDCells[rand.IntN(len(allowedDCells))]
 			}
 
-			// 创建新车辆
-			vehicle := element.NewVehicle(
-				getNextVehicleID(),
-				randomVelocity(),
-				randomAcceleration(),
-				1.0, // 车辆长度
-				randomSlowingProbability(),
-				false, // ClosedVehicle = false，完成后离开系统
-			)
+			// 按车队组成随机选择类别并创建新车辆
+			vehicle := newClassVehicle(getNextVehicleID(), sampleVehicleClass(), false) // ClosedVehicle = false，完成后离开系统
 
 			// 设置起点和终点
 			ok, err := vehicle.SetOD(g, oCell, dCell)
 			if !ok || err != nil {
 				return // 设置失败，跳过此车辆
 			}
+			setVehicleZones(vehicle, oCell, dCell)
 
 			// 计算路径（使用配置的路径查找方法）
 			path, _, err := pathFinder(g, oCell, dCell)
@@ -213,12 +170,18 @@ func GenerateScheduleVehicle(simTime, n int, g *simple.DirectedGraph, nodes []gr
 				return // 路径计算失败，跳过此车辆
 			}
 
+			// 根据以往经历的行程时间在已知路径和候选路径之间选择
+			path = chooseLearnedPath(vehicle.Index(), oCell, dCell, path)
+
 			// 设置路径
 			ok, err = vehicle.SetPath(path)
 			if !ok || err != nil {
 				return // 路径设置失败，跳过此车辆
 			}
 
+			// 写入模式下记录行程
+			recordTrip(vehicle, simTime, 0)
+
 			// 将车辆加入缓冲区
 			vehicle.BufferIn(simTime)
 
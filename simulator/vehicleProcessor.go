@@ -1,28 +1,32 @@
 package simulator
 
 import (
+	"fmt"
 	"simAndLearning/element"
+	"simAndLearning/log"
 	"simAndLearning/recorder"
 	"simAndLearning/utils"
 	"sync"
 	"sync/atomic"
 
-	"math/rand/v2"
-
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
 )
 
 // VehicleProcess 处理当前模拟环境中所有车辆的状态
-// 依次执行：检查已完成车辆、更新车辆激活状态、更新车辆位置、处理检查点
+// 依次执行：检查已完成车辆、让停留结束的车辆出发、更新车辆激活状态、途中重新规划路径、决定合流单元格放行的进口道、更新车辆位置
 func VehicleProcess(numWorkers, simTime int, g *simple.DirectedGraph) {
 	checkCompletedVehicle(simTime, g)
-	updateVehicleActiveStatus(numWorkers)
+	releaseDwellingVehicles(simTime, g)
+	updateVehicleActiveStatus(numWorkers, simTime)
+	rerouteVehicles(numWorkers, simTime, g)
+	resolveJunctions()
 	updateVehiclePosition(numWorkers, simTime)
 }
 
 // checkCompletedVehicle 处理已完成行程的车辆
-// 记录数据并根据车辆类型决定是否重新进入系统
+// 记录数据并根据车辆类型决定是否重新进入系统，配置了出行链的封闭车辆在活动地点停留后出发；
+// 每辆车只处理一次，无论能否重新进入系统都从完成列表中移除
 func checkCompletedVehicle(simTime int, g *simple.DirectedGraph) {
 	if len(completedVehicles) == 0 {
 		return
@@ -36,93 +40,145 @@ func checkCompletedVehicle(simTime int, g *simple.DirectedGraph) {
 		recorder.RecordVehicleData(vehicle)
 		// 记录车辆轨迹数据
 		recorder.RecordVehicleTrace(vehicle)
+		// 记录路径学习的行程时间
+		recordRouteExperience(vehicle)
 
 		// 仅处理闭环车辆（需要重新进入系统的车辆）
 		if vehicle.Flag() {
-			// 为车辆选择新的起点和终点
-			newO := vehicle.Destination()
-
-			// 根据是否启用距离限制选择不同的方式获取终点
-			var newD graph.Node
-			if isDistanceLimitEnabled() {
-				minLength, maxLength := TripDistanceRange()
-
-				// 获取可达节点
-				allowedDCells := utils.AccessibleNodesWithinRange(g, newO, minLength, maxLength)
-				if len(allowedDCells) == 0 {
-					continue // 如果没有可达节点，跳过此车辆
-				}
+			reenterClosedVehicle(vehicle, simTime, g, pathFinder)
+		}
 
-				newD = allowedDCells[rand.IntN(len(allowedDCells))]
-			} else {
-				// 即使不启用距离限制，也确保最小距离在1英里以上
-				minLength, _ := TripDistanceRange() // 使用TripDistanceRange获取最小距离，已确保大于1英里
-				allowedDCells := utils.AccessibleNodesWithinRange(g, newO, minLength, 1000000)
-				if len(allowedDCells) == 0 {
-					continue // 如果没有合适的终点，跳过此车辆
-				}
+		// 从完成列表中移除车辆
+		completedVehiclesMutex.Lock()
+		delete(completedVehicles, vehicle)
+		completedVehiclesMutex.Unlock()
+	}
+}
 
-				// 从可达节点中随机选择一个作为终点
-				newD = allowedDCells[rand.IntN(len(allowedDCells))]
-			}
+// reenterClosedVehicle 为完成行程的封闭车辆选择下一次行程，以相同ID和属性的新车辆重新进入系统
+// 上一行程终点小区没有出行需求时从其他小区出发；找不到终点或无法设置起终点时车辆退出系统；
+// 暂时找不到路径时车辆在起点停留，下一个时间步重试；回放模式下使用行程清单中记录的下一次行程
+func reenterClosedVehicle(vehicle *element.Vehicle, simTime int, g *simple.DirectedGraph, pathFinder utils.PathFinder) {
+	if TripListMode() == TRIP_LIST_REPLAY {
+		replayClosedTrip(vehicle, simTime, g, pathFinder)
+		return
+	}
 
-			// 保留原车辆的ID和属性，重新设置起点和终点
-			// 获取原车辆的各项属性
-			vehicleID := vehicle.Index()
-			vehicleVelocity := vehicle.Velocity()
-			vehicleAcceleration := vehicle.Acceleration()
-			vehicleOccupy := vehicle.Occupy() // 使用Occupy方法获取原车辆的占用空间
-			vehicleSlowingProb := vehicle.SlowingProb()
-
-			// 创建新车辆，保持原有属性
-			newVehicle := element.NewVehicle(
-				vehicleID,           // 保持原车辆ID
-				vehicleVelocity,     // 保持原车辆速度
-				vehicleAcceleration, // 保持原车辆加速度
-				vehicleOccupy,       // 保持原车辆占用空间
-				vehicleSlowingProb,  // 保持原车辆减速概率
-				true,                // 保持为闭环车辆(flag=true)
-			)
-
-			if ok, err := newVehicle.SetOD(g, newO, newD); !ok {
-				if err != nil {
-					// 记录错误并跳过此车辆
-					continue
-				}
-			}
+	// 为车辆选择新的起点和终点
+	newO := vehicle.Destination()
+
+	// 配置了交通小区时从上一行程终点小区的进口单元格出发，否则按出行距离分布选择终点
+	var newD graph.Node
+	var ok bool
+	if len(zones) > 0 {
+		_, destinationZone := vehicle.Zones()
+		if newO, newD, ok = sampleZoneOD(destinationZone); !ok {
+			// 该小区没有出行需求(如只有出口的小区)，从其他小区出发
+			newO, newD, ok = sampleZoneOD(-1)
+		}
+	} else {
+		newD, ok = sampleTripDestination(g, newO)
+	}
+	if !ok {
+		retireClosedVehicle(vehicle, "no destination")
+		return
+	}
 
-			// 设置路径（使用配置的路径查找方法）
-			path, _, err := pathFinder(g, newO, newD)
-			if err != nil {
-				continue // 如果无法找到路径，跳过此车辆
+	// 保留原车辆的ID和属性，重新设置起点和终点
+	// 获取原车辆的各项属性
+	vehicleID := vehicle.Index()
+	vehicleVelocity := vehicle.Velocity()
+	vehicleAcceleration := vehicle.Acceleration()
+	vehicleOccupy := vehicle.Occupy() // 使用Occupy方法获取原车辆的占用空间
+	vehicleSlowingProb := vehicle.SlowingProb()
+
+	// 创建新车辆，保持原有属性
+	newVehicle := element.NewVehicle(
+		vehicleID,           // 保持原车辆ID
+		vehicleVelocity,     // 保持原车辆速度
+		vehicleAcceleration, // 保持原车辆加速度
+		vehicleOccupy,       // 保持原车辆占用空间
+		vehicleSlowingProb,  // 保持原车辆减速概率
+		true,                // 保持为闭环车辆(flag=true)
+	)
+	newVehicle.SetClass(vehicle.Class(), vehicle.MaxSpeed()) // 保持原车辆类别
+	newVehicle.SetRerouting(vehicle.Rerouting())             // 保持原车辆是否使用导航
+
+	// 按出行链计划下一次行程，返回已访问的活动地点时以该地点为终点
+	dwell := 0
+	if toursEnabled() {
+		plan := planTourTrip(vehicle)
+		if plan.revisit != nil {
+			if d := revisitDestination(newO, plan.revisit); d != nil {
+				newD = d
 			}
+		}
+		newVehicle.SetTour(plan.tourID, plan.seq)
+		dwell = plan.dwell
+	}
 
-			if ok, err := newVehicle.SetPath(path); !ok {
-				if err != nil {
-					continue
-				}
-			}
+	if ok, err := newVehicle.SetOD(g, newO, newD); !ok || err != nil {
+		retireClosedVehicle(vehicle, "invalid OD")
+		return
+	}
+	setVehicleZones(newVehicle, newO, newD)
 
-			// 将车辆放入缓冲区
-			newVehicle.BufferIn(simTime)
+	// 写入模式下在车辆出发时记录行程
+	holdClosedTrip(newVehicle, dwell)
+
+	if dwell > 0 || !departVehicle(newVehicle, g, simTime, pathFinder) {
+		// 在活动地点停留，停留结束后出发；找不到路径时在下一个时间步重试
+		dwellingVehicles[newVehicle] = simTime + max(dwell, 1)
+	}
+}
+
+// retireClosedVehicle 记录无法重新进入系统的封闭车辆，车辆退出系统
+func retireClosedVehicle(vehicle *element.Vehicle, reason string) {
+	log.WriteLog(fmt.Sprintf("Closed Vehicle %d Retired: %s at %d", vehicle.Index(), reason, vehicle.Destination().ID()))
+}
 
-			// 添加到等待队列
-			waitingVehiclesMutex.Lock()
-			waitingVehicles[newVehicle] = struct{}{}
-			waitingVehiclesMutex.Unlock()
-			atomic.AddInt64(&numVehiclesWaiting, 1)
+// departVehicle 为已设置起终点的车辆计算路径，并放入起点缓冲区等待进入系统
+// 回放时保存了记录路径的车辆使用记录的路径，记录的路径无法设置时下次出发改为重新计算
+// 返回false表示无法找到或设置路径
+func departVehicle(vehicle *element.Vehicle, g *simple.DirectedGraph, simTime int, pathFinder utils.PathFinder) bool {
+	origin, destination := vehicle.Origin(), vehicle.Destination()
+
+	path, recorded := takeRecordedPath(vehicle)
+	if !recorded {
+		// 设置路径（使用配置的路径查找方法）
+		var err error
+		path, _, err = pathFinder(g, origin, destination)
+		if err != nil {
+			return false // 如果无法找到路径，跳过此车辆
 		}
 
-		// 从完成列表中移除车辆
-		completedVehiclesMutex.Lock()
-		delete(completedVehicles, vehicle)
-		completedVehiclesMutex.Unlock()
+		// 根据以往经历的行程时间在已知路径和候选路径之间选择
+		path = chooseLearnedPath(vehicle.Index(), origin, destination, path)
+	}
+
+	if ok, err := vehicle.SetPath(path); !ok {
+		if err != nil {
+			return false
+		}
 	}
+
+	// 写入模式下记录封闭车辆的行程
+	recordDeparture(vehicle, simTime)
+
+	// 将车辆放入缓冲区
+	vehicle.BufferIn(simTime)
+
+	// 添加到等待队列
+	waitingVehiclesMutex.Lock()
+	waitingVehicles[vehicle] = struct{}{}
+	waitingVehiclesMutex.Unlock()
+	atomic.AddInt64(&numVehiclesWaiting, 1)
+	return true
 }
 
 // updateVehicleActiveStatus 更新车辆的激活状态
 // 激活状态决定车辆是否能够从缓冲区进入系统
-func updateVehicleActiveStatus(numWorkers int) {
+func updateVehicleActiveStatus(numWorkers, simTime int) {
 	if len(waitingVehicles) == 0 {
 		return
 	}
@@ -152,8 +208,7 @@ func updateVehicleActiveStatus(numWorkers int) {
 		go func() {
 			for vehicle := range vehicleChan {
 				// 更新车辆激活状态
-				if vehicle.UpdateActiveState() {
-					vehicle.SystemIn()
+				if vehicle.UpdateActiveState() && vehicle.SystemIn(simTime) {
 					recordMutex.Lock() // 获取锁
 					recordActivatedVehicle[vehicle] = struct{}{}
 					recordMutex.Unlock() // 释放锁
@@ -0,0 +1,251 @@
+package simulator
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"simAndLearning/config"
+	"simAndLearning/element"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// 小区单元格的类型
+const (
+	ZONE_CELL_ENTRY = "entry" // 进口单元格，车辆从小区进入路网
+	ZONE_CELL_EXIT  = "exit"  // 出口单元格，车辆从路网到达小区
+	ZONE_CELL_BOTH  = "both"  // 同时作为进口和出口
+)
+
+// Zone 表示一个交通小区及其连接路网的进口和出口单元格
+// 小区的源缓冲区由各进口单元格的缓冲区组成，新车辆进入排队车辆最少的进口单元格
+type Zone struct {
+	id      int64
+	entries []graph.Node
+	exits   []graph.Node
+}
+
+// ID 返回小区ID
+func (z *Zone) ID() int64 {
+	return z.id
+}
+
+// Entries 返回小区的进口单元格
+func (z *Zone) Entries() []graph.Node {
+	return z.entries
+}
+
+// Exits 返回小区的出口单元格
+func (z *Zone) Exits() []graph.Node {
+	return z.exits
+}
+
+// entryCell 返回源缓冲区中排队车辆最少的进口单元格，排队相同时随机选择
+func (z *Zone) entryCell() graph.Node {
+	best := make([]graph.Node, 0, len(z.entries))
+	shortest := -1
+	for _, node := range z.entries {
+		queue := len(node.(element.Cell).ListBuffer())
+		if shortest < 0 || queue < shortest {
+			best = best[:0]
+			shortest = queue
+		}
+		if queue == shortest {
+			best = append(best, node)
+		}
+	}
+	return best[rand.IntN(len(best))]
+}
+
+// exitCell 随机返回一个出口单元格
+func (z *Zone) exitCell() graph.Node {
+	return z.exits[rand.IntN(len(z.exits))]
+}
+
+var (
+	// zones 保存当前路网中的交通小区，未配置时为空，起终点从所有单元格中选择
+	zones map[int64]*Zone = make(map[int64]*Zone)
+	// zoneIDs 按ID排列的小区ID，保证随机选择的顺序稳定
+	zoneIDs []int64
+	// cellZones 保存进口和出口单元格到所属小区的映射
+	cellZones map[int64]int64 = make(map[int64]int64)
+)
+
+// GetZones 返回当前路网中的交通小区
+func GetZones() map[int64]*Zone {
+	return zones
+}
+
+// ZoneOf 返回进口或出口单元格所属的小区ID
+func ZoneOf(node graph.Node) (int64, bool) {
+	zone, ok := cellZones[node.ID()]
+	return zone, ok
+}
+
+// LoadZones 按配置读取小区文件，替换交通小区注册表，未配置时清空注册表
+//
+// 参数:
+//   - g: 路网图，用于查找小区文件中的单元格
+//
+// 返回:
+//   - int: 小区数量
+//   - error: 如果读取或解析文件失败，或单元格属于多个小区，返回错误
+func LoadZones(g *simple.DirectedGraph) (int, error) {
+	zones = make(map[int64]*Zone)
+	zoneIDs = nil
+	cellZones = make(map[int64]int64)
+
+	cfg := config.GetConfig()
+	if cfg == nil || cfg.Demand.Zones.FilePath == "" {
+		return 0, nil
+	}
+
+	loaded, err := readZoneFile(cfg.Demand.Zones.FilePath, g)
+	if err != nil {
+		return 0, err
+	}
+	for id, zone := range loaded {
+		zoneIDs = append(zoneIDs, id)
+		for _, node := range append(append([]graph.Node{}, zone.entries...), zone.exits...) {
+			if other, ok := cellZones[node.ID()]; ok && other != id {
+				return 0, fmt.Errorf("单元格 %d 同时属于小区 %d 和 %d", node.ID(), other, id)
+			}
+			cellZones[node.ID()] = id
+		}
+	}
+	sort.Slice(zoneIDs, func(i, j int) bool { return zoneIDs[i] < zoneIDs[j] })
+	zones = loaded
+	return len(zones), nil
+}
+
+// readZoneFile 读取小区文件
+// 进口单元格不能是红绿灯单元格，避免行程从信号灯处开始
+//
+// 文件格式:
+//
+//	第一行为标题(Zone,Node,Type)
+//	之后每行包含小区ID、单元格ID和单元格类型(entry, exit, both)，省略类型时为both
+func readZoneFile(filePath string, g *simple.DirectedGraph) (map[int64]*Zone, error) {
+	records, err := readCSVRecords(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]*Zone)
+	for i, record := range records {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("%s 第%d行字段不足", filePath, i+2)
+		}
+		id, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("%s 第%d行小区ID无效: %s", filePath, i+2, record[0])
+		}
+		nodeID, err := strconv.ParseInt(record[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s 第%d行单元格ID无效: %v", filePath, i+2, err)
+		}
+		node := g.Node(nodeID)
+		if node == nil {
+			return nil, fmt.Errorf("%s 第%d行单元格 %d 不存在", filePath, i+2, nodeID)
+		}
+		cellType := ZONE_CELL_BOTH
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			cellType = strings.TrimSpace(record[2])
+		}
+		if cellType != ZONE_CELL_ENTRY && cellType != ZONE_CELL_EXIT && cellType != ZONE_CELL_BOTH {
+			return nil, fmt.Errorf("%s 第%d行单元格类型无效: %s", filePath, i+2, cellType)
+		}
+
+		zone, ok := result[id]
+		if !ok {
+			zone = &Zone{id: id}
+			result[id] = zone
+		}
+
+		if cellType == ZONE_CELL_ENTRY || cellType == ZONE_CELL_BOTH {
+			if _, isLight := node.(*element.TrafficLightCell); isLight {
+				return nil, fmt.Errorf("%s 第%d行红绿灯单元格 %d 不能作为进口", filePath, i+2, nodeID)
+			}
+			zone.entries = append(zone.entries, node)
+		}
+		if cellType == ZONE_CELL_EXIT || cellType == ZONE_CELL_BOTH {
+			zone.exits = append(zone.exits, node)
+		}
+	}
+	return result, nil
+}
+
+// sampleZoneOD 随机选择起终点小区，返回起点小区源缓冲区中的进口单元格和终点小区的出口单元格
+// 配置了出行需求矩阵时按矩阵份额选择小区对，否则在有进口的小区和有出口的其他小区中均匀选择
+//
+// 参数:
+//   - origin: 起点小区ID，-1表示随机选择起点小区
+//
+// 返回:
+//   - graph.Node: 起点(进口单元格)
+//   - graph.Node: 终点(出口单元格)
+//   - bool: 是否找到不同的起终点
+func sampleZoneOD(origin int64) (graph.Node, graph.Node, bool) {
+	var from *Zone
+	if origin >= 0 {
+		if from = zones[origin]; from == nil {
+			return nil, nil, false
+		}
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		o, d := from, (*Zone)(nil)
+		if odMatrix != nil {
+			pair, ok := odMatrix.samplePair(from)
+			if !ok {
+				return nil, nil, false
+			}
+			o, d = zones[pair[0]], zones[pair[1]]
+		} else {
+			if o == nil {
+				o = randomZone(func(z *Zone) bool { return len(z.entries) > 0 })
+			}
+			if o != nil {
+				d = randomZone(func(z *Zone) bool { return len(z.exits) > 0 && z != o })
+			}
+		}
+		if o == nil || d == nil || len(o.entries) == 0 || len(d.exits) == 0 {
+			return nil, nil, false
+		}
+
+		oCell, dCell := o.entryCell(), d.exitCell()
+		if oCell.ID() != dCell.ID() {
+			return oCell, dCell, true
+		}
+	}
+	return nil, nil, false
+}
+
+// setVehicleZones 按起终点单元格设置车辆所属的小区，不属于小区的一端为-1
+func setVehicleZones(vehicle *element.Vehicle, origin, destination graph.Node) {
+	originZone, destinationZone := int64(-1), int64(-1)
+	if zone, ok := ZoneOf(origin); ok {
+		originZone = zone
+	}
+	if zone, ok := ZoneOf(destination); ok {
+		destinationZone = zone
+	}
+	vehicle.SetZones(originZone, destinationZone)
+}
+
+// randomZone 在满足条件的小区中随机选择一个，没有满足条件的小区时返回nil
+func randomZone(accept func(*Zone) bool) *Zone {
+	candidates := make([]*Zone, 0, len(zoneIDs))
+	for _, id := range zoneIDs {
+		if accept(zones[id]) {
+			candidates = append(candidates, zones[id])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[rand.IntN(len(candidates))]
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"container/heap"
+	"simAndLearning/element"
+	"sort"
+	"sync"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// distanceCacheEntry 保存从一个起点出发可达的单元格及其最短网络距离，按距离升序排列
+type distanceCacheEntry struct {
+	nodes     []graph.Node
+	distances []int
+}
+
+var (
+	distanceCache      = make(map[int64]distanceCacheEntry)
+	distanceCacheMutex sync.RWMutex
+)
+
+// distanceItem 是最短距离搜索中优先队列的元素
+type distanceItem struct {
+	node     graph.Node
+	distance int
+}
+
+type distanceQueue []distanceItem
+
+func (q distanceQueue) Len() int            { return len(q) }
+func (q distanceQueue) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q distanceQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *distanceQueue) Push(x interface{}) { *q = append(*q, x.(distanceItem)) }
+func (q *distanceQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// NodesByDistance 返回从起点可达的所有单元格及其最短网络距离(单元格数)，按距离升序排列
+// 进入单元格的距离为1，进入路段的距离为路段长度，与AccessibleNodesWithinLim一致；结果按起点缓存
+//
+// 参数:
+//   - g: 路网图
+//   - from: 起点
+//
+// 返回:
+//   - []graph.Node: 可达的单元格，不包括起点
+//   - []int: 对应的最短网络距离
+func NodesByDistance(g graph.Graph, from graph.Node) ([]graph.Node, []int) {
+	distanceCacheMutex.RLock()
+	entry, ok := distanceCache[from.ID()]
+	distanceCacheMutex.RUnlock()
+	if ok {
+		return entry.nodes, entry.distances
+	}
+
+	settled := make(map[int64]bool)
+	queue := &distanceQueue{{node: from, distance: 0}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(distanceItem)
+		if settled[item.node.ID()] {
+			continue
+		}
+		settled[item.node.ID()] = true
+
+		if _, isCell := item.node.(element.Cell); isCell && item.node.ID() != from.ID() {
+			entry.nodes = append(entry.nodes, item.node)
+			entry.distances = append(entry.distances, item.distance)
+		}
+
+		for _, neighbor := range graph.NodesOf(g.From(item.node.ID())) {
+			if settled[neighbor.ID()] {
+				continue
+			}
+			switch n := neighbor.(type) {
+			case element.Cell:
+				heap.Push(queue, distanceItem{node: n, distance: item.distance + 1})
+			case *element.Link:
+				heap.Push(queue, distanceItem{node: n, distance: item.distance + n.Length()})
+			}
+		}
+	}
+
+	distanceCacheMutex.Lock()
+	distanceCache[from.ID()] = entry
+	distanceCacheMutex.Unlock()
+	return entry.nodes, entry.distances
+}
+
+// NodesWithinDistance 返回从起点出发最短网络距离在[lim1, lim2]内的单元格及其距离
+func NodesWithinDistance(g graph.Graph, from graph.Node, lim1, lim2 int) ([]graph.Node, []int) {
+	nodes, distances := NodesByDistance(g, from)
+	lower := sort.SearchInts(distances, lim1)
+	upper := sort.SearchInts(distances, lim2+1)
+	return nodes[lower:upper], distances[lower:upper]
+}
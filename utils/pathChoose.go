@@ -21,10 +21,18 @@ func GetPathFinder() PathFinder {
 		return ShortestPath
 	case "random":
 		return RandomPath
+	case "travelTime":
+		return TravelTimePath
 	case "kShortest":
 		return func(g *simple.DirectedGraph, origin, destination graph.Node) ([]graph.Node, float64, error) {
 			return ChooseFromKShortestPaths(g, origin, destination, cfg.Path.KShortest.K,
-				cfg.Path.KShortest.SelectionStrategy, cfg.Path.KShortest.LengthWeightFactor)
+				cfg.Path.KShortest.SelectionStrategy, cfg.Path.KShortest.LengthWeightFactor, LogitParams{
+					Dispersion:        cfg.Path.KShortest.Dispersion,
+					LengthCoef:        cfg.Path.KShortest.LengthCoef,
+					TimeCoef:          cfg.Path.KShortest.TimeCoef,
+					CommonalityFactor: cfg.Path.KShortest.CommonalityFactor,
+					PathSizeFactor:    cfg.Path.KShortest.PathSizeFactor,
+				})
 		}
 	default:
 		// 默认使用最短路径
@@ -33,8 +41,9 @@ func GetPathFinder() PathFinder {
 }
 
 // ChooseFromKShortestPaths 从k条最短路径中选择一条
+// logit、cLogit和pathSizeLogit策略按logitParams计算各路径的选择概率
 func ChooseFromKShortestPaths(g *simple.DirectedGraph, origin, destination graph.Node, k int,
-	strategy string, weightFactor float64) ([]graph.Node, float64, error) {
+	strategy string, weightFactor float64, logitParams LogitParams) ([]graph.Node, float64, error) {
 
 	// 获取k条最短路径
 	paths, err := KShortestPaths(g, origin, destination, k)
@@ -94,6 +103,11 @@ func ChooseFromKShortestPaths(g *simple.DirectedGraph, origin, destination graph
 
 		return paths[selectedIndex], lengths[selectedIndex], nil
 
+	case ROUTE_CHOICE_LOGIT, ROUTE_CHOICE_C_LOGIT, ROUTE_CHOICE_PATH_SIZE:
+		// 基于随机效用的Logit路径选择
+		selectedIndex := chooseByLogit(paths, strategy, logitParams)
+		return paths[selectedIndex], calPathLength(paths[selectedIndex]), nil
+
 	default:
 		// 默认返回最短的那条路径
 		return paths[0], calPathLength(paths[0]), nil
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"math"
+	"math/rand/v2"
+	"simAndLearning/element"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// 基于随机效用的路径选择模型
+const (
+	ROUTE_CHOICE_LOGIT     = "logit"         // 多项Logit
+	ROUTE_CHOICE_C_LOGIT   = "cLogit"        // C-Logit，以共性因子修正路径重叠
+	ROUTE_CHOICE_PATH_SIZE = "pathSizeLogit" // 路径规模Logit，以路径规模修正路径重叠
+)
+
+// LogitParams 保存Logit路径选择模型的参数
+// 路径的广义费用为LengthCoef*长度+TimeCoef*通行时间，系统效用为-Dispersion*广义费用
+type LogitParams struct {
+	Dispersion        float64 // 离散参数θ，越大越倾向于选择费用最低的路径
+	LengthCoef        float64 // 路径长度(单元格)的费用系数
+	TimeCoef          float64 // 路径通行时间(时间步)的费用系数
+	CommonalityFactor float64 // C-Logit中共性因子的系数β0
+	PathSizeFactor    float64 // 路径规模Logit中ln(PS)的系数
+}
+
+// chooseByLogit 按Logit模型计算各路径的选择概率并随机选择一条路径，返回所选路径的索引
+func chooseByLogit(paths [][]graph.Node, model string, params LogitParams) int {
+	cells := make([]map[int64]bool, len(paths))
+	for i, path := range paths {
+		cells[i] = pathCells(path)
+	}
+
+	utilities := make([]float64, len(paths))
+	for i, path := range paths {
+		cost := params.LengthCoef*calPathLength(path) + params.TimeCoef*PathTravelTime(path)
+		utilities[i] = -params.Dispersion * cost
+
+		switch model {
+		case ROUTE_CHOICE_C_LOGIT:
+			utilities[i] -= params.CommonalityFactor * math.Log(commonality(cells, i))
+		case ROUTE_CHOICE_PATH_SIZE:
+			utilities[i] += params.PathSizeFactor * math.Log(pathSize(cells, i))
+		}
+	}
+
+	return chooseByUtility(utilities)
+}
+
+// LogitChoice 按多项Logit模型根据各选项的费用随机选择一个选项，返回所选选项的索引
+func LogitChoice(costs []float64, dispersion float64) int {
+	utilities := make([]float64, len(costs))
+	for i, cost := range costs {
+		utilities[i] = -dispersion * cost
+	}
+	return chooseByUtility(utilities)
+}
+
+// chooseByUtility 以exp(效用)为权重随机选择一个选项
+func chooseByUtility(utilities []float64) int {
+	// 减去最大效用以避免指数溢出
+	maxUtility := math.Inf(-1)
+	for _, u := range utilities {
+		maxUtility = max(maxUtility, u)
+	}
+	weights := make([]float64, len(utilities))
+	totalWeight := 0.0
+	for i, u := range utilities {
+		weights[i] = math.Exp(u - maxUtility)
+		totalWeight += weights[i]
+	}
+
+	r := rand.Float64() * totalWeight
+	for i, weight := range weights {
+		if r < weight {
+			return i
+		}
+		r -= weight
+	}
+	return len(utilities) - 1
+}
+
+// commonality 计算C-Logit中路径i的共性因子的指数部分: Σj Lij/sqrt(Li*Lj)
+// 其中Lij为路径i和j共用的单元格数量，包含路径i自身，因此不小于1
+func commonality(cells []map[int64]bool, i int) float64 {
+	total := 0.0
+	for j := range cells {
+		shared := 0
+		for id := range cells[i] {
+			if cells[j][id] {
+				shared++
+			}
+		}
+		total += float64(shared) / math.Sqrt(float64(len(cells[i])*len(cells[j])))
+	}
+	return total
+}
+
+// pathSize 计算路径i的路径规模: Σa∈i (1/Li) * (1/Na)
+// 其中Na为使用单元格a的路径数量，与其他路径不重叠的路径规模为1
+func pathSize(cells []map[int64]bool, i int) float64 {
+	total := 0.0
+	for id := range cells[i] {
+		users := 0
+		for j := range cells {
+			if cells[j][id] {
+				users++
+			}
+		}
+		total += 1 / float64(users)
+	}
+	return total / float64(len(cells[i]))
+}
+
+// pathCells 返回路径经过的单元格ID集合，链路展开为其包含的单元格
+func pathCells(path []graph.Node) map[int64]bool {
+	cells := make(map[int64]bool, len(path))
+	for _, node := range path {
+		switch n := node.(type) {
+		case element.Cell:
+			cells[n.ID()] = true
+		case *element.Link:
+			for _, cell := range n.Flat() {
+				cells[cell.ID()] = true
+			}
+		}
+	}
+	return cells
+}
+
+// PathTravelTime 按当前的单元格通行时间计算路径除起点外各单元格的通行时间之和
+func PathTravelTime(path []graph.Node) float64 {
+	travelTime := 0.0
+	for _, node := range path[1:] {
+		switch n := node.(type) {
+		case element.Cell:
+			travelTime += CellTravelTime(n)
+		case *element.Link:
+			for _, cell := range n.Flat() {
+				travelTime += CellTravelTime(cell)
+			}
+		}
+	}
+	return travelTime
+}
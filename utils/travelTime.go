@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"simAndLearning/element"
+	"sync/atomic"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/path"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// minCellSpeed 估计单元格通行时间时使用的最低速度(单元格/时间步)，避免静止车辆使通行时间无穷大
+const minCellSpeed = 0.1
+
+// cellTravelTimes 保存最近一次更新的单元格通行时间(时间步)，更新时整体替换，路径查找时只读
+var cellTravelTimes atomic.Pointer[map[int64]float64]
+
+// UpdateTravelTimes 根据单元格当前的占用和车辆速度更新单元格通行时间
+// 当前估计值与上一次的结果按smoothing进行指数平滑，smoothing为1时只使用当前估计值
+//
+// 参数:
+//   - nodes: 路网中的所有单元格
+//   - smoothing: 当前估计值的权重，取值(0, 1]
+func UpdateTravelTimes(nodes []graph.Node, smoothing float64) {
+	if smoothing <= 0 || smoothing > 1 {
+		panic("smoothing must be between 0 and 1")
+	}
+
+	previous := cellTravelTimes.Load()
+	times := make(map[int64]float64, len(nodes))
+	for _, node := range nodes {
+		cell, ok := node.(element.Cell)
+		if !ok {
+			continue
+		}
+		current := estimateCellTravelTime(cell)
+		if previous != nil {
+			if last, ok := (*previous)[cell.ID()]; ok {
+				current = smoothing*current + (1-smoothing)*last
+			}
+		}
+		times[cell.ID()] = current
+	}
+	cellTravelTimes.Store(&times)
+}
+
+// estimateCellTravelTime 估计车辆通过单元格所需的时间步
+// 单元格中没有车辆时按限速自由通行；否则取车辆平均速度与按剩余容量折减的限速中的较大值
+func estimateCellTravelTime(cell element.Cell) float64 {
+	freeSpeed := float64(cell.MaxSpeed())
+	vehicles := cell.ListContainer()
+	if len(vehicles) == 0 {
+		return 1 / freeSpeed
+	}
+
+	totalSpeed := 0.0
+	for _, vehicle := range vehicles {
+		totalSpeed += float64(vehicle.ObservedVelocity())
+	}
+	avgSpeed := totalSpeed / float64(len(vehicles))
+	capacitySpeed := freeSpeed * (1 - cell.Occupation()/cell.Capacity())
+
+	return 1 / max(avgSpeed, capacitySpeed, minCellSpeed)
+}
+
+// CellTravelTime 返回单元格最近一次更新的通行时间，尚未更新时返回自由流通行时间
+func CellTravelTime(node graph.Node) float64 {
+	if times := cellTravelTimes.Load(); times != nil {
+		if t, ok := (*times)[node.ID()]; ok {
+			return t
+		}
+	}
+	if cell, ok := node.(element.Cell); ok {
+		return 1 / float64(cell.MaxSpeed())
+	}
+	return 1
+}
+
+// travelTimeGraph 以单元格通行时间为边权的路网图
+// 边(x, y)的权重为通过下游单元格y所需的时间
+type travelTimeGraph struct {
+	*simple.DirectedGraph
+}
+
+// Weight 返回边的通行时间，实现path.Weighted接口
+func (g travelTimeGraph) Weight(xid, yid int64) (float64, bool) {
+	if xid == yid {
+		return 0, true
+	}
+	if !g.HasEdgeFromTo(xid, yid) {
+		return math.Inf(1), false
+	}
+	return CellTravelTime(g.Node(yid)), true
+}
+
+// TravelTimePath 按当前的单元格通行时间查找通行时间最短的路径
+func TravelTimePath(g *simple.DirectedGraph, origin, destination graph.Node) ([]graph.Node, float64, error) {
+	shortestPath, travelTime := path.DijkstraFromTo(origin, destination, travelTimeGraph{g})
+	if len(shortestPath) < 2 {
+		return nil, -1, errors.New("no path found")
+	}
+	return shortestPath, travelTime, nil
+}